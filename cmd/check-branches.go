@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/hashicorp/go-multierror"
+	git "github.com/libgit2/git2go/v33"
+)
+
+// branchCheck is a branch not following the policy.
+type branchCheck struct {
+	*format.BranchViolation
+	// Remote of the branch, empty for local branches
+	Remote string
+	// Local branch, nil for remote branches
+	Local *git.Branch
+}
+
+// branchPolicy builds the branch policy from the repository configuration.
+// tug.branch.types is a comma separated list of allowed types, tug.branch.prefix and tug.branch.ignore are regular expressions.
+func branchPolicy(r *git.Repository) (*format.BranchPolicy, error) {
+	c, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Free()
+
+	user, _ := c.LookupString("user.name")
+	bp := format.DefaultBranchPolicy(user)
+	if types, _ := c.LookupString("tug.branch.types"); types != "" {
+		bp.Types = nil
+		for _, t := range strings.Split(types, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				bp.Types = append(bp.Types, t)
+			}
+		}
+	}
+	if prefix, _ := c.LookupString("tug.branch.prefix"); prefix != "" {
+		if bp.Prefix, err = regexp.Compile(prefix); err != nil {
+			return nil, fmt.Errorf("tug.branch.prefix: %w", err)
+		}
+	}
+	if ignore, _ := c.LookupString("tug.branch.ignore"); ignore != "" {
+		if bp.Ignore, err = regexp.Compile(ignore); err != nil {
+			return nil, fmt.Errorf("tug.branch.ignore: %w", err)
+		}
+	}
+	return bp, nil
+}
+
+// checkBranches returns the local and remote branches not following the policy.
+func checkBranches(r *git.Repository, bp *format.BranchPolicy) ([]*branchCheck, error) {
+	it, err := r.NewBranchIterator(git.BranchAll)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Free()
+
+	var checks []*branchCheck
+	err = it.ForEach(func(b *git.Branch, bt git.BranchType) error {
+		name, err := b.Name()
+		if err != nil {
+			return err
+		}
+		bc := &branchCheck{}
+		if bt == git.BranchRemote {
+			// Fallback on the first path component for remote branches without configured remote
+			if bc.Remote, err = r.RemoteName(b.Reference.Name()); err != nil {
+				bc.Remote = strings.SplitN(name, "/", 2)[0]
+			}
+			name = strings.TrimPrefix(name, bc.Remote+"/")
+		} else {
+			bc.Local = b
+		}
+		if bc.BranchViolation = bp.Check(name); bc.BranchViolation != nil {
+			checks = append(checks, bc)
+		}
+		return nil
+	})
+	return checks, err
+}
+
+// runCheckBranches reports the branches not following the policy, offering to rename local ones if rename is set.
+func runCheckBranches(opt *checkOpt) error {
+	bp, err := branchPolicy(opt.Repo)
+	if err != nil {
+		return err
+	}
+	checks, err := checkBranches(opt.Repo, bp)
+	if err != nil {
+		return err
+	}
+
+	merr := &multierror.Error{}
+	for _, bc := range checks {
+		if opt.Rename && bc.Local != nil && bc.Fix != nil {
+			rename := false
+			prompt := &survey.Confirm{Message: fmt.Sprintf("%s (%s). Rename to %s?", bc.Branch, bc.Msg, bc.Fix)}
+			if err := survey.AskOne(prompt, &rename); err != nil {
+				return err
+			}
+			if rename {
+				msg := tugit.ReflogMessage("check", fmt.Sprintf("renamed %s to %s", bc.Branch, bc.Fix))
+				renamed, err := tugit.RenameBranch(opt.Repo, bc.Local.Reference, bc.Fix.String(), msg)
+				if err != nil {
+					return err
+				}
+				renamed.Free()
+				continue
+			}
+		}
+		name := bc.Branch
+		if bc.Remote != "" {
+			name = bc.Remote + "/" + name
+		}
+		msg := fmt.Sprintf("branch %s %s", name, bc.Msg)
+		if bc.Fix != nil {
+			msg += fmt.Sprintf(" (rename to %s)", bc.Fix)
+		}
+		multierror.Append(merr, fmt.Errorf("%s", msg))
+	}
+	if merr.ErrorOrNil() == nil {
+		return nil
+	}
+	return &violationsError{merr}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBranches(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	c, err := tugit.Commit(r, "feat: initial commit")
+	require.NoError(t, err)
+	for _, b := range []string{"feat/ok-branch", "user/Alice/ok-branch", "feature/PROJ-1/login", "wip"} {
+		_, err := r.CreateBranch(b, c, false)
+		require.NoError(t, err)
+	}
+	_, err = r.Remotes.Create("origin", "https://example.com/repo.git")
+	require.NoError(t, err)
+	_, err = r.References.Create("refs/remotes/origin/bug/remote-branch", c.Id(), false, "")
+	require.NoError(t, err)
+
+	bp, err := branchPolicy(r)
+	require.NoError(t, err)
+	checks, err := checkBranches(r, bp)
+	require.NoError(t, err)
+
+	found := map[string]*branchCheck{}
+	for _, bc := range checks {
+		found[bc.Remote+":"+bc.Branch] = bc
+	}
+	assert.Len(t, found, 3)
+	if assert.Contains(t, found, ":feature/PROJ-1/login") {
+		assert.Equal(t, "feat/PROJ-1/login", found[":feature/PROJ-1/login"].Fix.String())
+		assert.NotNil(t, found[":feature/PROJ-1/login"].Local)
+	}
+	assert.Contains(t, found, ":wip")
+	if assert.Contains(t, found, "origin:bug/remote-branch") {
+		assert.Nil(t, found["origin:bug/remote-branch"].Local)
+	}
+
+	err = runCheckBranches(&checkOpt{Branches: true, Repo: r})
+	assert.IsType(t, &violationsError{}, err)
+
+	// Configured types
+	conf, err := r.Config()
+	require.NoError(t, err)
+	require.NoError(t, conf.SetString("tug.branch.types", "feat, fix, user"))
+	require.NoError(t, conf.SetString("tug.branch.ignore", "^(?:master|wip)$"))
+	bp, err = branchPolicy(r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat", "fix", "user"}, bp.Types)
+	checks, err = checkBranches(r, bp)
+	require.NoError(t, err)
+	assert.Len(t, checks, 2)
+}
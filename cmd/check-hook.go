@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	git "github.com/libgit2/git2go/v33"
+)
+
+// Git hooks check can run as
+const (
+	HOOK_PRE_PUSH    = "pre-push"
+	HOOK_PRE_RECEIVE = "pre-receive"
+)
+
+// refUpdate is a reference update received by a pre-push or pre-receive hook.
+type refUpdate struct {
+	Ref string
+	Old *git.Oid
+	New *git.Oid
+}
+
+// parseRefUpdates reads the reference updates git passes on the standard input of a hook.
+// pre-push lines are '<local ref> <local sha> <remote ref> <remote sha>', pre-receive lines are '<old> <new> <ref>'.
+func parseRefUpdates(r io.Reader, hook string) ([]*refUpdate, error) {
+	var updates []*refUpdate
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var ref, old, new string
+		switch {
+		case hook == HOOK_PRE_PUSH && len(fields) == 4:
+			ref, new, old = fields[2], fields[1], fields[3]
+		case hook == HOOK_PRE_RECEIVE && len(fields) == 3:
+			old, new, ref = fields[0], fields[1], fields[2]
+		default:
+			return nil, fmt.Errorf("malformed %s input line %d: '%s'", hook, n, scanner.Text())
+		}
+		oldID, err := git.NewOid(old)
+		if err != nil {
+			return nil, fmt.Errorf("malformed %s input line %d: %w", hook, n, err)
+		}
+		newID, err := git.NewOid(new)
+		if err != nil {
+			return nil, fmt.Errorf("malformed %s input line %d: %w", hook, n, err)
+		}
+		updates = append(updates, &refUpdate{Ref: ref, Old: oldID, New: newID})
+	}
+	return updates, scanner.Err()
+}
+
+// hookConflict returns an error naming the option of opt that cannot be used along with the hook mode, if any.
+func hookConflict(hook string, opt *checkOpt) error {
+	conflict := ""
+	switch {
+	case opt.Hook != "":
+		conflict = "--" + opt.Hook
+	case opt.Auto:
+		conflict = "--auto"
+	case opt.All:
+		conflict = "--all"
+	case len(opt.Revisions) > 0:
+		conflict = "revision ranges"
+	default:
+		return nil
+	}
+	return fmt.Errorf("--%s cannot be used with %s", hook, conflict)
+}
+
+// IsDeletion returns true if the update deletes the reference.
+func (u *refUpdate) IsDeletion() bool {
+	return u.New.IsZero()
+}
+
+// IsCreation returns true if the update creates the reference.
+func (u *refUpdate) IsCreation() bool {
+	return u.Old.IsZero()
+}
+
+// newHookWalk returns a walk over the commits introduced by the update, or nil if it introduces none.
+func newHookWalk(r *git.Repository, u *refUpdate, hook string) (*git.RevWalk, error) {
+	if u.IsDeletion() {
+		return nil, nil
+	}
+	walk, err := r.Walk()
+	if err != nil {
+		return nil, err
+	}
+	if err := walk.Push(u.New); err != nil {
+		walk.Free()
+		return nil, err
+	}
+
+	// The remote sha of a pre-push may be unknown locally, if someone else pushed in between
+	known := !u.IsCreation()
+	if known {
+		if _, err := r.LookupCommit(u.Old); err != nil {
+			known = false
+		}
+	}
+	if known {
+		err = walk.Hide(u.Old)
+	} else if hook == HOOK_PRE_PUSH {
+		// Anything already known by a remote is not new
+		err = walk.HideGlob("refs/remotes/*")
+	} else {
+		// References are not updated yet during pre-receive
+		err = walk.HideGlob("refs/*")
+	}
+	if err != nil {
+		walk.Free()
+		return nil, err
+	}
+	return walk, nil
+}
+
+// checkRefUpdates checks the commits introduced by every update.
+func checkRefUpdates(r *git.Repository, updates []*refUpdate, hook string, report *checkReport, skip *skipPolicy, rules commitRules, filters ...LogFilter) error {
+	seen := map[string]bool{}
+	for _, u := range updates {
+		walk, err := newHookWalk(r, u, hook)
+		if err != nil {
+			return fmt.Errorf("%s: %w", u.Ref, err)
+		}
+		if walk == nil {
+			continue
+		}
+		from := len(report.Commits)
+		err = walk.Iterate(walker(report, skip, rules, filters...))
+		walk.Free()
+		if err != nil {
+			return fmt.Errorf("%s: %w", u.Ref, err)
+		}
+		// Commits introduced on several references are reported once
+		added := report.Commits[from:]
+		report.Commits = report.Commits[:from]
+		for _, cc := range added {
+			if seen[cc.Hash] {
+				continue
+			}
+			seen[cc.Hash] = true
+			cc.Ref = u.Ref
+			report.Commits = append(report.Commits, cc)
+		}
+	}
+	return nil
+}
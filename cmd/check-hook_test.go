@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	zeroSha = "0000000000000000000000000000000000000000"
+	sha1    = "1111111111111111111111111111111111111111"
+	sha2    = "2222222222222222222222222222222222222222"
+)
+
+func TestParseRefUpdates(t *testing.T) {
+	updates, err := parseRefUpdates(strings.NewReader(fmt.Sprintf("refs/heads/main %s refs/heads/main %s\n\nrefs/heads/gone %s refs/heads/gone %s\n", sha2, sha1, zeroSha, sha1)), HOOK_PRE_PUSH)
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+	assert.Equal(t, "refs/heads/main", updates[0].Ref)
+	assert.Equal(t, sha1, updates[0].Old.String())
+	assert.Equal(t, sha2, updates[0].New.String())
+	assert.True(t, updates[1].IsDeletion())
+
+	updates, err = parseRefUpdates(strings.NewReader(fmt.Sprintf("%s %s refs/heads/new\n", zeroSha, sha2)), HOOK_PRE_RECEIVE)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, "refs/heads/new", updates[0].Ref)
+	assert.True(t, updates[0].IsCreation())
+
+	_, err = parseRefUpdates(strings.NewReader(fmt.Sprintf("%s %s refs/heads/new\n", zeroSha, sha2)), HOOK_PRE_PUSH)
+	assert.EqualError(t, err, fmt.Sprintf("malformed pre-push input line 1: '%s %s refs/heads/new'", zeroSha, sha2))
+	_, err = parseRefUpdates(strings.NewReader("foo bar refs/heads/new\n"), HOOK_PRE_RECEIVE)
+	assert.Error(t, err)
+}
+
+func TestHookConflict(t *testing.T) {
+	tcs := map[string]struct {
+		opt      *checkOpt
+		expected string
+	}{
+		"No conflict": {&checkOpt{}, ""},
+		"Other hook":  {&checkOpt{Hook: HOOK_PRE_PUSH}, "--pre-receive cannot be used with --pre-push"},
+		"Auto":        {&checkOpt{Auto: true}, "--pre-receive cannot be used with --auto"},
+		"All":         {&checkOpt{All: true}, "--pre-receive cannot be used with --all"},
+		"Revisions":   {&checkOpt{Revisions: []string{"main..HEAD"}}, "--pre-receive cannot be used with revision ranges"},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			err := hookConflict(HOOK_PRE_RECEIVE, tc.opt)
+			if tc.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRunCheckHook(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	c1, err := tugit.Commit(r, "bad commit 1")
+	require.NoError(t, err)
+	c2, err := tugit.Commit(r, "feat: ok commit")
+	require.NoError(t, err)
+	c3, err := tugit.Commit(r, "bad commit 2")
+	require.NoError(t, err)
+	sid3, err := c3.ShortId()
+	require.NoError(t, err)
+
+	// Update of an existing branch
+	stdin := fmt.Sprintf("%s %s refs/heads/main\n", c1.Id(), c2.Id())
+	assert.NoError(t, runCheck(&checkOpt{Hook: HOOK_PRE_RECEIVE, Stdin: strings.NewReader(stdin), Repo: r}))
+
+	// Same commit pushed on two references, along with a deletion
+	stdin = fmt.Sprintf("%s %s refs/heads/main\n%s %s refs/heads/feature\n%s %s refs/heads/gone\n", c2.Id(), c3.Id(), c2.Id(), c3.Id(), c1.Id(), zeroSha)
+	err = runCheck(&checkOpt{Hook: HOOK_PRE_RECEIVE, Stdin: strings.NewReader(stdin), Repo: r})
+	assert.EqualError(t, err, fmt.Sprintf("1 error occurred:\n\t* %s ('bad commit 2') on refs/heads/main is not compliant\n\n", sid3))
+
+	// New branch, nothing known by the remote
+	stdin = fmt.Sprintf("refs/heads/main %s refs/heads/main %s\n", c2.Id(), zeroSha)
+	err = runCheck(&checkOpt{Hook: HOOK_PRE_PUSH, Stdin: strings.NewReader(stdin), Repo: r})
+	assert.Error(t, err)
+
+	// Unknown remote sha
+	stdin = fmt.Sprintf("refs/heads/main %s refs/heads/main %s\n", c2.Id(), sha1)
+	err = runCheck(&checkOpt{Hook: HOOK_PRE_PUSH, Stdin: strings.NewReader(stdin), Repo: r})
+	assert.Error(t, err)
+}
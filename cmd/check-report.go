@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	git "github.com/libgit2/git2go/v33"
+)
+
+// Rules reported by check
+const (
+	RULE_CONVENTIONAL = "conventional-header"
+	RULE_STRICT       = "strict-header"
+	RULE_SIGNOFF      = "signoff"
+)
+
+// Check statuses of a commit
+const (
+	CHECK_PASSED  = "passed"
+	CHECK_FAILED  = "failed"
+	CHECK_SKIPPED = "skipped"
+)
+
+// checkRules describes the rules reported by check.
+var checkRules = map[string]string{
+	RULE_CONVENTIONAL: "The commit header must follow the conventional commit specification",
+	RULE_STRICT:       "The commit header must strictly follow the conventional commit specification, with a known type",
+	RULE_SIGNOFF:      "The commit must be signed off by its author (Signed-off-by: name <email>)",
+}
+
+// checkReport gathers the check results of every checked commit.
+type checkReport struct {
+	Commits []*commitCheck
+}
+
+// commitCheck is the check result of a single commit.
+type commitCheck struct {
+	Hash       string      `json:"hash"`
+	ShortHash  string      `json:"short_hash"`
+	Author     string      `json:"author"`
+	Email      string      `json:"email"`
+	Header     string      `json:"header"`
+	Ref        string      `json:"ref,omitempty"`
+	Status     string      `json:"status"`
+	SkipReason string      `json:"skip_reason,omitempty"`
+	Violations []violation `json:"violations,omitempty"`
+}
+
+// violation is a rule a commit does not follow.
+type violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func newCommitCheck(c *git.Commit) *commitCheck {
+	sid, err := c.ShortId()
+	if err != nil {
+		sid = c.Id().String()
+	}
+	return &commitCheck{
+		Hash:      c.Id().String(),
+		ShortHash: sid,
+		Author:    c.Author().Name,
+		Email:     c.Author().Email,
+		Header:    c.Summary(),
+		Status:    CHECK_PASSED,
+	}
+}
+
+// fail records a rule violation.
+func (cc *commitCheck) fail(rule string, msg string) {
+	cc.Status = CHECK_FAILED
+	cc.Violations = append(cc.Violations, violation{Rule: rule, Message: msg})
+}
+
+// skip marks the commit as skipped on purpose.
+func (cc *commitCheck) skip(reason string) {
+	cc.Status = CHECK_SKIPPED
+	cc.SkipReason = reason
+}
+
+// count returns the number of commits with the given status.
+func (cr *checkReport) count(status string) int {
+	n := 0
+	for _, cc := range cr.Commits {
+		if cc.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// violationsError is returned when at least one commit is not compliant.
+type violationsError struct {
+	merr *multierror.Error
+}
+
+func (e *violationsError) Error() string {
+	return e.merr.Error()
+}
+
+// Err returns a *violationsError listing every violation, or nil if all the commits are compliant.
+func (cr *checkReport) Err() error {
+	merr := &multierror.Error{}
+	for _, cc := range cr.Commits {
+		for _, v := range cc.Violations {
+			if cc.Ref != "" {
+				multierror.Append(merr, fmt.Errorf("%s ('%s') on %s %s", cc.ShortHash, cc.Header, cc.Ref, v.Message))
+			} else {
+				multierror.Append(merr, fmt.Errorf("%s ('%s') %s", cc.ShortHash, cc.Header, v.Message))
+			}
+		}
+	}
+	if merr.ErrorOrNil() == nil {
+		return nil
+	}
+	return &violationsError{merr}
+}
+
+// checkReportWriters maps report formats to their writer.
+var checkReportWriters = map[string]func(io.Writer, *checkReport) error{
+	"json":               writeJSONReport,
+	"junit":              writeJUnitReport,
+	"sarif":              writeSARIFReport,
+	"gitlab-codequality": writeCodeQualityReport,
+	"github-annotations": writeGitHubAnnotations,
+}
+
+func checkReportFormats() []string {
+	formats := make([]string, 0, len(checkReportWriters))
+	for f := range checkReportWriters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func writeJSONReport(w io.Writer, cr *checkReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Compliant bool           `json:"compliant"`
+		Commits   []*commitCheck `json:"commits"`
+	}{
+		Compliant: cr.count(CHECK_FAILED) == 0,
+		Commits:   cr.Commits,
+	})
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Skipped   *junitSkipped  `xml:"skipped,omitempty"`
+	Failures  []junitFailure `xml:"failure,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnitReport(w io.Writer, cr *checkReport) error {
+	suite := junitTestSuite{Name: "tug check", Tests: len(cr.Commits), Failures: cr.count(CHECK_FAILED), Skipped: cr.count(CHECK_SKIPPED)}
+	for _, cc := range cr.Commits {
+		tc := junitTestCase{Name: fmt.Sprintf("%s %s", cc.ShortHash, cc.Header), ClassName: "tug.check"}
+		if cc.Status == CHECK_SKIPPED {
+			tc.Skipped = &junitSkipped{Message: cc.SkipReason}
+		}
+		for _, v := range cc.Violations {
+			tc.Failures = append(tc.Failures, junitFailure{
+				Message: v.Message,
+				Type:    v.Rule,
+				Text:    fmt.Sprintf("commit %s\nAuthor: %s <%s>\n\n%s", cc.Hash, cc.Author, cc.Email, cc.Header),
+			})
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func writeSARIFReport(w io.Writer, cr *checkReport) error {
+	driver := sarifDriver{Name: BIN_NAME, InformationURI: "https://github.com/b4nst/turbogit", Version: Version}
+	ids := make([]string, 0, len(checkRules))
+	for id := range checkRules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		driver.Rules = append(driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: checkRules[id]}})
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, cc := range cr.Commits {
+		for _, v := range cc.Violations {
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  v.Rule,
+				Level:   "error",
+				Message: sarifMessage{Text: fmt.Sprintf("Commit %s ('%s') by %s <%s> %s", cc.ShortHash, cc.Header, cc.Author, cc.Email, v.Message)},
+				Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{
+					{Name: cc.ShortHash, FullyQualifiedName: cc.Hash, Kind: "commit"},
+				}}},
+				PartialFingerprints: map[string]string{"commitSha": cc.Hash},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+func writeCodeQualityReport(w io.Writer, cr *checkReport) error {
+	issues := []codeQualityIssue{}
+	for _, cc := range cr.Commits {
+		for _, v := range cc.Violations {
+			fp := md5.Sum([]byte(cc.Hash + v.Rule))
+			issues = append(issues, codeQualityIssue{
+				Description: fmt.Sprintf("Commit %s ('%s') by %s %s", cc.ShortHash, cc.Header, cc.Author, v.Message),
+				CheckName:   v.Rule,
+				Fingerprint: hex.EncodeToString(fp[:]),
+				Severity:    "major",
+				// Commits have no file, the short hash is the most meaningful location
+				Location: codeQualityLocation{Path: cc.ShortHash, Lines: codeQualityLines{Begin: 1}},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
+
+// escapeAnnotation escapes a GitHub workflow command value.
+func escapeAnnotation(s string, property bool) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	s = strings.ReplaceAll(s, "\n", "%0A")
+	if property {
+		s = strings.ReplaceAll(s, ":", "%3A")
+		s = strings.ReplaceAll(s, ",", "%2C")
+	}
+	return s
+}
+
+func writeGitHubAnnotations(w io.Writer, cr *checkReport) error {
+	for _, cc := range cr.Commits {
+		for _, v := range cc.Violations {
+			title := escapeAnnotation(fmt.Sprintf("tug check (%s)", v.Rule), true)
+			msg := escapeAnnotation(fmt.Sprintf("%s ('%s') by %s %s", cc.ShortHash, cc.Header, cc.Author, v.Message), false)
+			if _, err := fmt.Fprintf(w, "::error title=%s::%s\n", title, msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCheckReport() *checkReport {
+	bad := &commitCheck{Hash: "0123456789abcdef", ShortHash: "0123456", Author: "Alice", Email: "alice@ecorp.com", Header: "bad, commit: 100%", Status: CHECK_PASSED}
+	bad.fail(RULE_CONVENTIONAL, "is not compliant")
+	return &checkReport{Commits: []*commitCheck{
+		{Hash: "fedcba9876543210", ShortHash: "fedcba9", Author: "Bob", Email: "bob@ecorp.com", Header: "feat: ok", Status: CHECK_PASSED},
+		bad,
+	}}
+}
+
+func TestCheckReportErr(t *testing.T) {
+	assert.NoError(t, (&checkReport{}).Err())
+
+	err := testCheckReport().Err()
+	assert.IsType(t, &violationsError{}, err)
+	assert.EqualError(t, err, "1 error occurred:\n\t* 0123456 ('bad, commit: 100%') is not compliant\n\n")
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeJSONReport(&buf, testCheckReport()))
+
+	var out struct {
+		Compliant bool
+		Commits   []commitCheck
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.False(t, out.Compliant)
+	assert.Len(t, out.Commits, 2)
+	assert.Equal(t, CHECK_FAILED, out.Commits[1].Status)
+	assert.Equal(t, []violation{{Rule: RULE_CONVENTIONAL, Message: "is not compliant"}}, out.Commits[1].Violations)
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeJUnitReport(&buf, testCheckReport()))
+
+	out := buf.String()
+	assert.Contains(t, out, `<testsuites tests="2" failures="1">`)
+	assert.Contains(t, out, `<testcase name="fedcba9 feat: ok" classname="tug.check"></testcase>`)
+	assert.Contains(t, out, `<failure message="is not compliant" type="conventional-header">commit 0123456789abcdef`)
+}
+
+func TestWriteSARIFReport(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeSARIFReport(&buf, testCheckReport()))
+
+	var out sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Len(t, out.Runs, 1)
+	require.Len(t, out.Runs[0].Results, 1)
+	assert.Equal(t, RULE_CONVENTIONAL, out.Runs[0].Results[0].RuleID)
+	assert.Equal(t, "0123456789abcdef", out.Runs[0].Results[0].PartialFingerprints["commitSha"])
+}
+
+func TestWriteCodeQualityReport(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeCodeQualityReport(&buf, testCheckReport()))
+
+	var out []codeQualityIssue
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Len(t, out, 1)
+	assert.Equal(t, RULE_CONVENTIONAL, out[0].CheckName)
+	assert.Equal(t, "0123456", out[0].Location.Path)
+	assert.Len(t, out[0].Fingerprint, 32)
+}
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeGitHubAnnotations(&buf, testCheckReport()))
+
+	assert.Equal(t, "::error title=tug check (conventional-header)::0123456 ('bad, commit: 100%25') by Alice is not compliant\n", buf.String())
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	git "github.com/libgit2/git2go/v33"
+)
+
+// Reasons for skipping a commit
+const (
+	SKIP_MERGE     = "merge commit"
+	SKIP_GENERATED = "generated by git"
+	SKIP_BASELINE  = "before baseline"
+	SKIP_ALLOWLIST = "in allowlist"
+)
+
+// Headers generated by git revert, commit --fixup and commit --squash
+var generatedHeader = regexp.MustCompile(`^(?:Revert ".*"|(?:fixup|squash|amend)! .*)$`)
+
+// skipPolicy decides which commits check ignores on purpose.
+type skipPolicy struct {
+	// Skip merge commits
+	Merges bool
+	// Skip reverts, fixups and squashes generated by git
+	Generated bool
+	// Skip the baseline commit and its ancestors
+	Baseline *git.Oid
+	// Skip commits committed before this date
+	Before *time.Time
+	// Grandfathered commit hashes, possibly abbreviated
+	Allowlist []string
+
+	repo *git.Repository
+	// The baseline commit and its ancestors, walked once
+	beforeBaseline map[git.Oid]struct{}
+}
+
+// newSkipPolicy returns a policy skipping nothing.
+func newSkipPolicy(r *git.Repository) *skipPolicy {
+	return &skipPolicy{repo: r}
+}
+
+// SetBaseline sets the baseline from a revision, or from a date if baseline is not a revision.
+func (sp *skipPolicy) SetBaseline(baseline string) error {
+	if obj, err := sp.repo.RevparseSingle(baseline); err == nil {
+		defer obj.Free()
+		commit, err := obj.Peel(git.ObjectCommit)
+		if err != nil {
+			return err
+		}
+		defer commit.Free()
+		sp.Baseline = commit.Id()
+		return sp.walkBaseline()
+	}
+	date, err := dateparse.ParseAny(baseline)
+	if err != nil {
+		return fmt.Errorf("baseline '%s' is neither a revision nor a date", baseline)
+	}
+	sp.Before = &date
+	return nil
+}
+
+// walkBaseline collects the baseline commit and its ancestors.
+func (sp *skipPolicy) walkBaseline() error {
+	walk, err := sp.repo.Walk()
+	if err != nil {
+		return err
+	}
+	defer walk.Free()
+	if err := walk.Push(sp.Baseline); err != nil {
+		return err
+	}
+	sp.beforeBaseline = map[git.Oid]struct{}{}
+	return walk.Iterate(func(c *git.Commit) bool {
+		sp.beforeBaseline[*c.Id()] = struct{}{}
+		return true
+	})
+}
+
+// LoadAllowlist reads grandfathered commit hashes from a file, one per line.
+// Blank lines and lines starting with '#' are ignored, and anything after the hash is treated as a comment.
+func (sp *skipPolicy) LoadAllowlist(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sha := strings.ToLower(strings.Fields(line)[0])
+		if len(sha) < 7 || strings.Trim(sha, "0123456789abcdef") != "" {
+			return fmt.Errorf("%s:%d: '%s' is not a commit hash", path, n, sha)
+		}
+		sp.Allowlist = append(sp.Allowlist, sha)
+	}
+	return scanner.Err()
+}
+
+// Skip returns the reason why c must be skipped, or an empty string if it must be checked.
+func (sp *skipPolicy) Skip(c *git.Commit) string {
+	if sp == nil {
+		return ""
+	}
+	if sp.Merges && c.ParentCount() > 1 {
+		return SKIP_MERGE
+	}
+	if sp.Generated && generatedHeader.MatchString(c.Summary()) {
+		return SKIP_GENERATED
+	}
+	hash := c.Id().String()
+	for _, sha := range sp.Allowlist {
+		if strings.HasPrefix(hash, sha) {
+			return SKIP_ALLOWLIST
+		}
+	}
+	if sp.Before != nil && c.Committer().When.Before(*sp.Before) {
+		return SKIP_BASELINE
+	}
+	if _, ok := sp.beforeBaseline[*c.Id()]; ok {
+		return SKIP_BASELINE
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipPolicy(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	old, err := tugit.Commit(r, "old bad commit")
+	require.NoError(t, err)
+	grandfathered, err := tugit.Commit(r, "grandfathered bad commit")
+	require.NoError(t, err)
+	revert, err := tugit.Commit(r, "Revert \"feat: something\"")
+	require.NoError(t, err)
+	fixup, err := tugit.Commit(r, "fixup! feat: something")
+	require.NoError(t, err)
+	bad, err := tugit.Commit(r, "bad commit")
+	require.NoError(t, err)
+
+	allowlist := filepath.Join(r.Workdir(), "allowlist")
+	require.NoError(t, ioutil.WriteFile(allowlist, []byte("# grandfathered\n"+grandfathered.Id().String()[:10]+" legacy\n"), 0644))
+	defer os.Remove(allowlist)
+
+	sp := newSkipPolicy(r)
+	sp.Generated = true
+	require.NoError(t, sp.SetBaseline(old.Id().String()))
+	require.NoError(t, sp.LoadAllowlist(allowlist))
+
+	for c, expected := range map[string]string{
+		old.Id().String():           SKIP_BASELINE,
+		grandfathered.Id().String(): SKIP_ALLOWLIST,
+		revert.Id().String():        SKIP_GENERATED,
+		fixup.Id().String():         SKIP_GENERATED,
+		bad.Id().String():           "",
+	} {
+		oid, err := tugit.ResolveCommit(r, c)
+		require.NoError(t, err)
+		commit, err := r.LookupCommit(oid)
+		require.NoError(t, err)
+		assert.Equal(t, expected, sp.Skip(commit), commit.Summary())
+	}
+
+	// Only the last commit is reported as failed
+	report := &checkReport{}
+	walk, err := newRevWalk(r, false, "HEAD", nil, false)
+	require.NoError(t, err)
+	defer walk.Free()
+	require.NoError(t, walk.Iterate(walker(report, sp, commitRules{})))
+	assert.Equal(t, 1, report.count(CHECK_FAILED))
+	assert.Equal(t, 4, report.count(CHECK_SKIPPED))
+}
+
+func TestSkipPolicyErrors(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+
+	sp := newSkipPolicy(r)
+	assert.EqualError(t, sp.SetBaseline("not a date"), "baseline 'not a date' is neither a revision nor a date")
+	assert.NoError(t, sp.SetBaseline("2022-01-01"))
+	assert.NotNil(t, sp.Before)
+
+	allowlist := filepath.Join(r.Workdir(), "allowlist")
+	require.NoError(t, ioutil.WriteFile(allowlist, []byte("zzz\n"), 0644))
+	assert.EqualError(t, sp.LoadAllowlist(allowlist), allowlist+":1: 'zzz' is not a commit hash")
+}
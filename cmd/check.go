@@ -22,94 +22,326 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/b4nst/turbogit/internal/cmdbuilder"
+	"github.com/b4nst/turbogit/pkg/ci"
+	"github.com/b4nst/turbogit/pkg/filter"
 	"github.com/b4nst/turbogit/pkg/format"
-	"github.com/hashicorp/go-multierror"
+	tugit "github.com/b4nst/turbogit/pkg/git"
 	git "github.com/libgit2/git2go/v33"
 	"github.com/spf13/cobra"
 )
 
+const (
+	// Exit code when every checked commit is compliant
+	CHECK_EXIT_COMPLIANT = 0
+	// Exit code when at least one commit is not compliant
+	CHECK_EXIT_NON_COMPLIANT = 1
+	// Exit code when the check could not run
+	CHECK_EXIT_ERROR = 2
+)
+
 func init() {
 	RootCmd.AddCommand(CheckCmd)
 
 	CheckCmd.Flags().BoolP("all", "a", false, "Check all the commits in refs/*, along with HEAD")
-	CheckCmd.Flags().StringP("from", "f", "HEAD", "Commit to start from. Can be a hash or any revision as accepted by rev parse.")
+	CheckCmd.Flags().StringP("from", "f", "HEAD", "Commit to start from. Can be a hash or any revision as accepted by rev parse. Ignored if revision ranges are given.")
+	CheckCmd.Flags().Bool("auto", false, "Only check the commits of the current merge request, detected from the CI environment (GitLab, GitHub) or from origin/HEAD")
+	CheckCmd.Flags().Bool(HOOK_PRE_PUSH, false, "Run as a pre-push hook, checking the pushed commits read from the standard input")
+	CheckCmd.Flags().Bool(HOOK_PRE_RECEIVE, false, "Run as a pre-receive hook, checking the received commits read from the standard input")
+	CheckCmd.Flags().Bool("branches", false, "Check local and remote branch names instead of commits")
+	CheckCmd.Flags().Bool("rename", false, "With --branches, offer to rename the non conforming local branches")
+	CheckCmd.Flags().Bool("strict", false, "Reject unknown or abbreviated types, empty scopes, blank descriptions and malformed breaking change markers")
+	CheckCmd.Flags().Bool("require-signoff", false, "Require a Signed-off-by footer matching the commit author (Developer Certificate of Origin)")
+	CheckCmd.Flags().Bool("skip-merges", false, "Skip merge commits")
+	CheckCmd.Flags().Bool("skip-generated", false, "Skip 'Revert \"...\"', 'fixup!' and 'squash!' commits generated by git")
+	CheckCmd.Flags().String("baseline", "", "Skip commits before a baseline, given as a revision (skipping it and its ancestors) or a date")
+	CheckCmd.Flags().String("allowlist", "", "Skip grandfathered commits listed in a file, one hash per line")
+	CheckCmd.Flags().StringP("where", "w", "", "Only check commits matching an expression (e.g. '!(author =~ bot)')")
+	CheckCmd.Flags().StringP("report", "r", "", fmt.Sprintf("Write a report on the standard output %s", checkReportFormats()))
+	CheckCmd.RegisterFlagCompletionFunc("report", reportFlagCompletion)
+
+	// A check that cannot run exits with CHECK_EXIT_ERROR, not with the default exit code of cobra
+	cmdbuilder.AppendPreRun(CheckCmd, func(cmd *cobra.Command, args []string) {
+		checkCmdErr(cmdbuilder.OpenRepo(cmd))
+	})
+	CheckCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		checkCmdErr(err)
+		return nil
+	})
+}
 
-	cmdbuilder.RepoAware(CheckCmd)
+func reportFlagCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return checkReportFormats(), cobra.ShellCompDirectiveDefault
 }
 
 var CheckCmd = &cobra.Command{
-	Use:   "check",
+	Use:   "check [<revision range>...]",
 	Short: "Ensure the history follows conventional commit",
+	Long: fmt.Sprintf(`
+Exit codes are stable: %d if every checked commit is compliant, %d if at least one commit is not compliant
+and %d if the check could not run.
+
+With --auto, the range to check is detected from the environment: the merge request diff base on GitLab,
+the pull request base or the pushed commits on GitHub actions, and the merge base with origin/HEAD otherwise.
+`, CHECK_EXIT_COMPLIANT, CHECK_EXIT_NON_COMPLIANT, CHECK_EXIT_ERROR),
 	Example: `
 # Run a check from HEAD
 $ tug check
+
+# Check only the commits of a feature branch
+$ tug check main..HEAD
+
+# Check the commits of the current merge request in the CI
+$ tug check --auto
+
+# Do not check commits authored by the bot
+$ tug check --where '!(author =~ bot)'
+
+# Reject anything but the exact conventional commit syntax
+$ tug check --strict
+
+# Require every commit to be signed off by its author
+$ tug check --require-signoff
+
+# Grandfather the history before v1.0.0 and ignore commits generated by git
+$ tug check --baseline v1.0.0 --skip-generated
+
+# Check branch names (see tug.branch.types, tug.branch.prefix and tug.branch.ignore) and offer to fix them
+$ tug check --branches --rename
+
+# Use as a pre-receive hook on a git server
+$ tug check --pre-receive
+
+# Write a JUnit report for the CI
+$ tug check --report junit > tug-check.xml
 `,
-	Args: cobra.NoArgs,
+	Args: cobra.ArbitraryArgs,
 
 	Run: func(cmd *cobra.Command, args []string) {
-		opt := &checkOpt{}
+		opt := &checkOpt{Revisions: args}
 		var err error
 
 		opt.All, err = cmd.Flags().GetBool("all")
-		cobra.CheckErr(err)
+		checkCmdErr(err)
 
 		opt.From, err = cmd.Flags().GetString("from")
-		cobra.CheckErr(err)
+		checkCmdErr(err)
+
+		opt.Auto, err = cmd.Flags().GetBool("auto")
+		checkCmdErr(err)
+		if opt.Auto && (opt.All || len(opt.Revisions) > 0) {
+			checkCmdErr(errors.New("--auto cannot be used with --all or revision ranges"))
+		}
+
+		for _, hook := range []string{HOOK_PRE_PUSH, HOOK_PRE_RECEIVE} {
+			enabled, err := cmd.Flags().GetBool(hook)
+			checkCmdErr(err)
+			if !enabled {
+				continue
+			}
+			checkCmdErr(hookConflict(hook, opt))
+			opt.Hook = hook
+		}
+		opt.Stdin = os.Stdin
 
 		opt.Repo = cmdbuilder.GetRepo(cmd)
+		if opt.Hook != "" {
+			// Pushed objects are quarantined in directories given by the environment
+			opt.Repo, err = tugit.OpenFromEnv(opt.Repo.Path())
+			checkCmdErr(err)
+		}
+
+		opt.Branches, err = cmd.Flags().GetBool("branches")
+		checkCmdErr(err)
+		opt.Rename, err = cmd.Flags().GetBool("rename")
+		checkCmdErr(err)
 
-		cobra.CheckErr(runCheck(opt))
+		opt.Strict, err = cmd.Flags().GetBool("strict")
+		checkCmdErr(err)
+		opt.RequireSignOff, err = cmd.Flags().GetBool("require-signoff")
+		checkCmdErr(err)
 
-		cmd.Println("repository compliant.")
+		opt.Skip = newSkipPolicy(opt.Repo)
+		opt.Skip.Merges, err = cmd.Flags().GetBool("skip-merges")
+		checkCmdErr(err)
+		opt.Skip.Generated, err = cmd.Flags().GetBool("skip-generated")
+		checkCmdErr(err)
+		fBaseline, err := cmd.Flags().GetString("baseline")
+		checkCmdErr(err)
+		if fBaseline != "" {
+			checkCmdErr(opt.Skip.SetBaseline(fBaseline))
+		}
+		fAllowlist, err := cmd.Flags().GetString("allowlist")
+		checkCmdErr(err)
+		if fAllowlist != "" {
+			checkCmdErr(opt.Skip.LoadAllowlist(fAllowlist))
+		}
+
+		fWhere, err := cmd.Flags().GetString("where")
+		checkCmdErr(err)
+		if fWhere != "" {
+			opt.Where, err = filter.Compile(fWhere)
+			checkCmdErr(err)
+		}
+
+		opt.Report, err = cmd.Flags().GetString("report")
+		checkCmdErr(err)
+		if opt.Report != "" {
+			if _, ok := checkReportWriters[opt.Report]; !ok {
+				checkCmdErr(fmt.Errorf("unknown report format '%s', expected one of %s", opt.Report, checkReportFormats()))
+			}
+		}
+
+		err = runCheck(opt)
+		var verr *violationsError
+		if errors.As(err, &verr) {
+			if opt.Hook != "" {
+				fmt.Fprintln(os.Stderr, "Push rejected, the following commits do not follow conventional commit:")
+			}
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(CHECK_EXIT_NON_COMPLIANT)
+		}
+		checkCmdErr(err)
+
+		if opt.Branches {
+			cmd.Println("branches compliant.")
+		} else {
+			cmd.Println("repository compliant.")
+		}
 	},
 }
 
+// checkCmdErr prints the error and exits with CHECK_EXIT_ERROR, if err is not nil.
+func checkCmdErr(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(CHECK_EXIT_ERROR)
+	}
+}
+
 type checkOpt struct {
-	All  bool
-	From string
-	Repo *git.Repository
+	All            bool
+	From           string
+	Revisions      []string
+	Auto           bool
+	Hook           string
+	Stdin          io.Reader
+	Where          *filter.Expression
+	Skip           *skipPolicy
+	Strict         bool
+	RequireSignOff bool
+	Branches       bool
+	Rename         bool
+	Report         string
+	Repo           *git.Repository
+}
+
+// commitRules are the optional rules checked on every commit.
+type commitRules struct {
+	// Validate the header strictly
+	Strict bool
+	// Require a sign-off of the author
+	SignOff bool
 }
 
 func runCheck(opt *checkOpt) error {
-	walk, err := opt.Repo.Walk()
-	if err != nil {
-		return err
+	if opt.Branches {
+		return runCheckBranches(opt)
 	}
-	if opt.All {
-		if err := walk.PushGlob("refs/*"); err != nil {
+	revs := opt.Revisions
+	if opt.Auto {
+		rg, err := ci.DetectRange(opt.Repo, os.Getenv)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Checking %s (%s)\n", rg.Spec, rg.Source)
+		revs = []string{rg.Spec}
+	}
+	report := &checkReport{}
+	rules := commitRules{Strict: opt.Strict, SignOff: opt.RequireSignOff}
+	filters := []LogFilter{Where(opt.Where, CommitterDate)}
+	if opt.Hook != "" {
+		updates, err := parseRefUpdates(opt.Stdin, opt.Hook)
+		if err != nil {
+			return err
+		}
+		if err := checkRefUpdates(opt.Repo, updates, opt.Hook, report, opt.Skip, rules, filters...); err != nil {
 			return err
 		}
 	} else {
-		from, err := opt.Repo.RevparseSingle(opt.From)
+		walk, err := newRevWalk(opt.Repo, opt.All, opt.From, revs, false)
 		if err != nil {
 			return err
 		}
-		if err := walk.Push(from.Id()); err != nil {
+		defer walk.Free()
+		if err := walk.Iterate(walker(report, opt.Skip, rules, filters...)); err != nil {
 			return err
 		}
 	}
-
-	merr := &multierror.Error{}
-	if err := walk.Iterate(walker(merr)); err != nil {
-		return err
+	if n := report.count(CHECK_SKIPPED); n > 0 {
+		fmt.Fprintf(os.Stderr, "%d commit(s) skipped.\n", n)
+	}
+	if opt.Report != "" {
+		if err := checkReportWriters[opt.Report](os.Stdout, report); err != nil {
+			return err
+		}
 	}
-	return merr.ErrorOrNil()
+	return report.Err()
 }
 
-func walker(merr *multierror.Error) git.RevWalkIterator {
+func walker(report *checkReport, skip *skipPolicy, rules commitRules, filters ...LogFilter) git.RevWalkIterator {
 	return func(c *git.Commit) bool {
-		sid, err := c.ShortId()
-		if err != nil {
-			multierror.Append(merr, err)
+		co := format.ParseCommitMsg(c.Message())
+		if keep, walk := ApplyFilters(c, orEmpty(co), filters...); !keep {
+			return walk
+		}
+		cc := newCommitCheck(c)
+		report.Commits = append(report.Commits, cc)
+		if reason := skip.Skip(c); reason != "" {
+			cc.skip(reason)
 			return true
 		}
-		co := format.ParseCommitMsg(c.Message())
-		if co == nil {
-			multierror.Append(merr, fmt.Errorf("%s ('%s') is not compliant", sid, c.Summary()))
+		if rules.Strict {
+			if err := format.ValidateHeader(c.Summary()); err != nil {
+				cc.fail(RULE_STRICT, fmt.Sprintf("is not compliant: %s", err))
+			}
+		} else if co == nil {
+			cc.fail(RULE_CONVENTIONAL, "is not compliant")
+		}
+		if rules.SignOff {
+			if msg := checkSignOff(c); msg != "" {
+				cc.fail(RULE_SIGNOFF, msg)
+			}
 		}
 		return true
 	}
 }
+
+// checkSignOff returns why the commit is not signed off by its author, or an empty string if it is.
+func checkSignOff(c *git.Commit) string {
+	a := c.Author()
+	signoffs := format.SignOffs(c.Message())
+	if len(signoffs) == 0 {
+		return "is not signed off"
+	}
+	for _, ident := range signoffs {
+		if name, email := format.ParseIdent(ident); name == a.Name && strings.EqualFold(email, a.Email) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("is not signed off by its author %s <%s>", a.Name, a.Email)
+}
+
+// orEmpty returns co or an empty message option if co is nil, so that filters can be applied to non compliant commits.
+func orEmpty(co *format.CommitMessageOption) *format.CommitMessageOption {
+	if co == nil {
+		return &format.CommitMessageOption{}
+	}
+	return co
+}
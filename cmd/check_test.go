@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/b4nst/turbogit/pkg/filter"
 	tugit "github.com/b4nst/turbogit/pkg/git"
 	"github.com/b4nst/turbogit/pkg/test"
 	"github.com/stretchr/testify/assert"
@@ -29,3 +36,109 @@ func TestRunCheck(t *testing.T) {
 	err = runCheck(&checkOpt{All: false, From: "HEAD", Repo: r})
 	assert.EqualError(t, err, fmt.Sprintf("2 errors occurred:\n\t* %s ('bad commit 2') is not compliant\n\t* %s ('bad commit 1') is not compliant\n\n", sid3, sid1))
 }
+
+func TestRunCheckWhere(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	_, err := tugit.Commit(r, "bad commit")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "feat: ok commit")
+	require.NoError(t, err)
+
+	where, err := filter.Compile("type == feat")
+	require.NoError(t, err)
+	err = runCheck(&checkOpt{From: "HEAD", Where: where, Repo: r})
+	assert.NoError(t, err)
+}
+
+func TestRunCheckRange(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	_, err := tugit.Commit(r, "bad commit")
+	require.NoError(t, err)
+	base, err := tugit.Commit(r, "feat: base")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "fix: ok commit")
+	require.NoError(t, err)
+
+	err = runCheck(&checkOpt{Revisions: []string{base.Id().String() + "..HEAD"}, Repo: r})
+	assert.NoError(t, err)
+
+	require.NoError(t, os.Setenv("CI_MERGE_REQUEST_DIFF_BASE_SHA", base.Id().String()))
+	defer os.Unsetenv("CI_MERGE_REQUEST_DIFF_BASE_SHA")
+	err = runCheck(&checkOpt{Auto: true, Repo: r})
+	assert.NoError(t, err)
+}
+
+func TestRunCheckStrict(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	_, err := tugit.Commit(r, "feat: ok commit")
+	require.NoError(t, err)
+	c, err := tugit.Commit(r, "foo: unknown type")
+	require.NoError(t, err)
+	sid, err := c.ShortId()
+	require.NoError(t, err)
+
+	assert.NoError(t, runCheck(&checkOpt{From: "HEAD", Repo: r}))
+	err = runCheck(&checkOpt{From: "HEAD", Strict: true, Repo: r})
+	assert.EqualError(t, err, fmt.Sprintf("1 error occurred:\n\t* %s ('foo: unknown type') is not compliant: unknown commit type 'foo', expected one of build, ci, chore, docs, feat, fix, perf, refactor, style, test, auto, revert at position 1\n\n", sid))
+}
+
+func TestRunCheckSignOff(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	_, err := tugit.Commit(r, "feat: signed\n\nSigned-off-by: "+test.GIT_USERNAME+" <"+test.GIT_EMAIL+">")
+	require.NoError(t, err)
+	assert.NoError(t, runCheck(&checkOpt{From: "HEAD", RequireSignOff: true, Repo: r}))
+
+	unsigned, err := tugit.Commit(r, "fix: unsigned")
+	require.NoError(t, err)
+	other, err := tugit.Commit(r, "fix: other\n\nSigned-off-by: Bob <bob@example.com>")
+	require.NoError(t, err)
+	usid, err := unsigned.ShortId()
+	require.NoError(t, err)
+	osid, err := other.ShortId()
+	require.NoError(t, err)
+
+	assert.NoError(t, runCheck(&checkOpt{From: "HEAD", Repo: r}))
+	err = runCheck(&checkOpt{From: "HEAD", RequireSignOff: true, Repo: r})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("%s ('fix: unsigned') is not signed off", usid))
+	assert.Contains(t, err.Error(), fmt.Sprintf("%s ('fix: other') is not signed off by its author %s <%s>", osid, test.GIT_USERNAME, test.GIT_EMAIL))
+}
+
+func TestCheckCmdExitError(t *testing.T) {
+	if args := os.Getenv("TUG_TEST_CHECK_ARGS"); args != "" {
+		RootCmd.SetArgs(strings.Fields(args))
+		Execute()
+		return
+	}
+	dir, err := ioutil.TempDir("", "tug-not-a-repo-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tcs := map[string]string{
+		"not a repository": "check",
+		"unknown flag":     "check --unknown",
+	}
+	for name, args := range tcs {
+		t.Run(name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestCheckCmdExitError$")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "TUG_TEST_CHECK_ARGS="+args, "GIT_CEILING_DIRECTORIES="+filepath.Dir(dir))
+			err := cmd.Run()
+			var exitErr *exec.ExitError
+			require.True(t, errors.As(err, &exitErr), "expected an exit error, got %v", err)
+			assert.Equal(t, CHECK_EXIT_ERROR, exitErr.ExitCode())
+		})
+	}
+}
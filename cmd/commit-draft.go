@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Directory of the message drafts, relative to the git directory
+	DRAFTS_DIR = "tug-drafts"
+	// Draft name when HEAD is detached
+	DETACHED_DRAFT = "HEAD"
+)
+
+// draftName returns the name of the current branch draft.
+func draftName(r *git.Repository) string {
+	head, err := r.Head()
+	if err == nil {
+		defer head.Free()
+		if head.IsBranch() {
+			return head.Shorthand()
+		}
+		return DETACHED_DRAFT
+	}
+	// Unborn branch
+	if ref, err := r.References.Lookup("HEAD"); err == nil {
+		defer ref.Free()
+		if target := ref.SymbolicTarget(); target != "" {
+			return strings.TrimPrefix(target, "refs/heads/")
+		}
+	}
+	return DETACHED_DRAFT
+}
+
+// draftPath returns the draft file of the current branch.
+func draftPath(r *git.Repository) string {
+	return filepath.Join(r.Path(), DRAFTS_DIR, filepath.FromSlash(draftName(r)))
+}
+
+// saveDraft keeps msg as the draft of the current branch.
+func saveDraft(r *git.Repository, msg string) error {
+	p := draftPath(r)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, []byte(msg), 0644)
+}
+
+// fromDraft returns the draft of the current branch as initial message.
+func fromDraft(r *git.Repository) (string, *git.Commit, error) {
+	if err := tugit.PreCommitHook(r); err != nil {
+		return "", nil, fmt.Errorf("Error during pre-commit hook: %s", err.Error())
+	}
+	raw, err := ioutil.ReadFile(draftPath(r))
+	if os.IsNotExist(err) {
+		return "", nil, fmt.Errorf("No message draft for %s", draftName(r))
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return string(raw), nil, nil
+}
+
+// cleanDrafts removes the draft of the current branch, and the drafts of branches that no longer exist.
+func cleanDrafts(r *git.Repository) error {
+	dir := filepath.Join(r.Path(), DRAFTS_DIR)
+	if err := os.Remove(draftPath(r)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if name == DETACHED_DRAFT {
+			return nil
+		}
+		if _, err := r.LookupBranch(filepath.ToSlash(name), git.BranchLocal); err != nil {
+			return os.Remove(p)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
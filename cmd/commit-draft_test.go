@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrafts(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	_, _, err := fromDraft(r)
+	assert.EqualError(t, err, "No message draft for "+draftName(r))
+
+	require.NoError(t, saveDraft(r, "feat: draft"))
+	msg, _, err := fromDraft(r)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: draft", msg)
+
+	// Draft of a deleted branch
+	gone := filepath.Join(r.Path(), DRAFTS_DIR, "feat", "gone")
+	require.NoError(t, os.MkdirAll(filepath.Dir(gone), 0755))
+	require.NoError(t, ioutil.WriteFile(gone, []byte("feat: gone"), 0644))
+
+	require.NoError(t, cleanDrafts(r))
+	assert.NoFileExists(t, draftPath(r))
+	assert.NoFileExists(t, gone)
+}
+
+func TestCommitDraft(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	test.StageNewFile(t, r)
+	defer os.Setenv("GIT_EDITOR", os.Getenv("GIT_EDITOR"))
+
+	// Rejected message is kept
+	require.NoError(t, os.Setenv("GIT_EDITOR", "sed -i -e 's/feat: //'"))
+	err := runCommit(&commitOpt{CType: format.FeatureCommit, Message: "draft", PromptEditor: true, Repo: r})
+	assert.Error(t, err)
+	raw, err := ioutil.ReadFile(draftPath(r))
+	require.NoError(t, err)
+	assert.Equal(t, "draft", string(raw))
+
+	// Reopened and fixed
+	require.NoError(t, os.Setenv("GIT_EDITOR", "sed -i -e 's/^draft$/fix: draft/'"))
+	require.NoError(t, runCommit(&commitOpt{ReuseDraft: true, PromptEditor: true, Repo: r}))
+	assert.NoFileExists(t, draftPath(r))
+	head, err := r.Head()
+	require.NoError(t, err)
+	c, err := r.LookupCommit(head.Target())
+	require.NoError(t, err)
+	assert.Equal(t, "fix: draft", c.Message())
+}
+
+func TestCommitDraftBody(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	test.StageNewFile(t, r)
+	defer os.Setenv("GIT_EDITOR", os.Getenv("GIT_EDITOR"))
+
+	draft := "Login timeout\n\nUsers were logged out\nafter five minutes.\n\nThe session is now refreshed.\n\nRefs: PROJ-12"
+	require.NoError(t, saveDraft(r, draft))
+	require.NoError(t, os.Setenv("GIT_EDITOR", "sed -i -e 's/^Login timeout$/fix: login timeout/'"))
+	require.NoError(t, runCommit(&commitOpt{ReuseDraft: true, PromptEditor: true, Repo: r}))
+	head, err := r.Head()
+	require.NoError(t, err)
+	c, err := r.LookupCommit(head.Target())
+	require.NoError(t, err)
+	assert.Equal(t, "fix: login timeout\n\nUsers were logged out\nafter five minutes.\n\nThe session is now refreshed.\n\nRefs: PROJ-12", c.Message())
+}
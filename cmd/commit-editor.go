@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Everything below this line is ignored, as with git commit --cleanup=scissors
+	SCISSORS_LINE = "# ------------------------ >8 ------------------------"
+	// File the message is edited in, relative to the git directory
+	COMMIT_EDITMSG = "COMMIT_EDITMSG"
+)
+
+// stripComments removes the comment lines and everything below the scissors line of a commit message file.
+func stripComments(msg string) string {
+	var lines []string
+	for _, l := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(l, SCISSORS_LINE) {
+			break
+		}
+		if strings.HasPrefix(l, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(l, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// lintCommitMessage returns an error if msg is not a valid conventional commit message.
+// Headers are also validated strictly when tug.strict is set.
+func lintCommitMessage(r *git.Repository, msg string) error {
+	header := strings.SplitN(msg, "\n", 2)[0]
+	co := format.ParseCommitMsg(msg)
+	if co == nil {
+		return fmt.Errorf("'%s' does not follow conventional commit (<type>[(<scope>)][!]: <description>)", header)
+	}
+	if err := co.Check(); err != nil {
+		return err
+	}
+	c, err := r.Config()
+	if err != nil {
+		return err
+	}
+	defer c.Free()
+	if strict, _ := c.LookupBool("tug.strict"); strict {
+		if err := format.ValidateHeader(header); err != nil {
+			var herr *format.HeaderError
+			if errors.As(err, &herr) {
+				return fmt.Errorf("%s\n%s", herr, herr.Pointer())
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// editCommitMessage opens the git editor on COMMIT_EDITMSG, filled with msg and a commented context.
+// It returns the edited message without comments, aborts on an empty message,
+// and offers to edit again while the message does not pass the lint. A message failing the lint is returned with the error.
+func editCommitMessage(r *git.Repository, msg string) (string, error) {
+	editor := tugit.Editor(r)
+	file := filepath.Join(r.Path(), COMMIT_EDITMSG)
+	content := msg + "\n" + commitContext(r)
+	for {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			return "", err
+		}
+		if err := tugit.LaunchEditor(editor, file); err != nil {
+			return "", fmt.Errorf("There was a problem with the editor '%s': %w", editor, err)
+		}
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		edited := stripComments(string(raw))
+		if edited == "" {
+			return "", errors.New("Aborting commit due to empty commit message.")
+		}
+		lerr := lintCommitMessage(r, edited)
+		if lerr == nil {
+			return edited, nil
+		}
+
+		again := false
+		prompt := &survey.Confirm{Message: fmt.Sprintf("%s. Edit again?", lerr), Default: true}
+		if err := survey.AskOne(prompt, &again); err != nil || !again {
+			return edited, lerr
+		}
+		// Keep the user edits
+		content = string(raw)
+	}
+}
+
+// commitContext returns the commented help, branch, linked issue, allowed types and staged files
+// appended to the edited message.
+func commitContext(r *git.Repository) string {
+	var sb strings.Builder
+	sb.WriteString("\n# Please enter the commit message for your changes. Lines starting\n")
+	sb.WriteString("# with '#' will be ignored, and an empty message aborts the commit.\n#\n")
+
+	if head, err := r.Head(); err == nil {
+		if head.IsBranch() {
+			fmt.Fprintf(&sb, "# On branch %s\n", head.Shorthand())
+			if tb, err := format.ParseBranch(head.Shorthand()); err == nil && format.DefaultBranchPrefix.MatchString(tb.Prefix) {
+				fmt.Fprintf(&sb, "# Linked issue: %s\n", tb.Prefix)
+			}
+		} else {
+			fmt.Fprintf(&sb, "# HEAD detached at %s\n", head.Target().String()[:7])
+		}
+		head.Free()
+	}
+	fmt.Fprintf(&sb, "# Allowed types: %s\n", strings.Join(format.AllCommitType(), ", "))
+
+	diff, err := tugit.StagedDiff(r)
+	if err != nil {
+		return sb.String()
+	}
+	defer diff.Free()
+	n, err := diff.NumDeltas()
+	if err != nil || n == 0 {
+		return sb.String()
+	}
+	sb.WriteString("#\n# Changes to be committed:\n")
+	for i := 0; i < n; i++ {
+		delta, err := diff.Delta(i)
+		if err != nil {
+			break
+		}
+		fmt.Fprintf(&sb, "#\t%-12s%s\n", deltaLabel(delta.Status)+":", delta.NewFile.Path)
+	}
+	return sb.String()
+}
+
+// deltaLabel returns the git status label of a staged change.
+func deltaLabel(status git.Delta) string {
+	switch status {
+	case git.DeltaAdded:
+		return "new file"
+	case git.DeltaDeleted:
+		return "deleted"
+	case git.DeltaRenamed:
+		return "renamed"
+	case git.DeltaTypeChange:
+		return "typechange"
+	default:
+		return "modified"
+	}
+}
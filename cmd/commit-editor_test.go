@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripComments(t *testing.T) {
+	msg := "feat: foo  \n# comment\n\nbody\n#\n" + SCISSORS_LINE + "\ndiff --git a/foo b/foo\n"
+	assert.Equal(t, "feat: foo\n\nbody", stripComments(msg))
+	assert.Equal(t, "", stripComments("# only\n# comments\n"))
+}
+
+func TestEditCommitMessage(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	test.StageNewFile(t, r)
+	defer os.Setenv("GIT_EDITOR", os.Getenv("GIT_EDITOR"))
+
+	tcs := map[string]struct {
+		editor string
+		msg    string
+		err    string
+	}{
+		"Unchanged": {"true", "feat: foo", ""},
+		"Edited":    {"sed -i -e 's/foo/bar/'", "feat: bar", ""},
+		"Empty":     {"sed -i -e '/^[^#]/d'", "", "Aborting commit due to empty commit message."},
+		"Not compliant": {"sed -i -e 's/feat: //'", "",
+			"'foo' does not follow conventional commit (<type>[(<scope>)][!]: <description>)"},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.Setenv("GIT_EDITOR", tc.editor))
+			msg, err := editCommitMessage(r, "feat: foo")
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.msg, msg)
+		})
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(r.Path(), COMMIT_EDITMSG))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "# Changes to be committed:\n#\tnew file:")
+	assert.Contains(t, string(raw), "# Allowed types: ")
+}
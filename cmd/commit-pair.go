@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/ktr0731/go-fuzzyfinder"
+	git "github.com/libgit2/git2go/v33"
+)
+
+// pickCoAuthors lets the user pick the co-authors of the commit from the roster.
+func pickCoAuthors(r *git.Repository) ([]string, error) {
+	roster, err := tugit.Roster(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(roster) == 0 {
+		return nil, fmt.Errorf("No co-author to pair with, add some with 'git config --add %s \"Name <email>\"'", tugit.ROSTER_CONFIG)
+	}
+	idx, err := fuzzyfinder.FindMulti(roster,
+		func(i int) string {
+			return roster[i]
+		},
+		fuzzyfinder.WithHeader("Select the co-authors (tab to select several)"))
+	if errors.Is(err, fuzzyfinder.ErrAbort) {
+		return nil, errors.New("No co-author selected.")
+	}
+	if err != nil {
+		return nil, err
+	}
+	coauthors := make([]string, 0, len(idx))
+	for _, i := range idx {
+		coauthors = append(coauthors, roster[i])
+	}
+	return coauthors, nil
+}
+
+// coAuthorFooter returns the footer crediting a co-author.
+func coAuthorFooter(ident string) string {
+	return format.COAUTHOR_FOOTER + ": " + ident
+}
+
+// signOffFooter returns the Signed-off-by footer of the commit signature.
+func signOffFooter(r *git.Repository) (string, error) {
+	sig, err := r.DefaultSignature()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s: %s <%s>", format.SIGNOFF_FOOTER, sig.Name, sig.Email), nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/integrations"
+	"github.com/b4nst/turbogit/pkg/scope"
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Group of the staged files at the repository root
+	ROOT_GROUP = "."
+)
+
+// splitGroup is a set of staged files committed together.
+type splitGroup struct {
+	// Mapped scope or top level directory of the files
+	Name  string
+	Paths []string
+}
+
+// splitStaged groups the staged paths by mapped scope, or by top level directory for unmapped paths.
+// Groups are sorted by name.
+func splitStaged(paths []string, m scope.Map) []*splitGroup {
+	byName := map[string]*splitGroup{}
+	var groups []*splitGroup
+	for _, p := range paths {
+		name := m.Of(p)
+		if name == "" {
+			name = ROOT_GROUP
+			if i := strings.Index(p, "/"); i > 0 {
+				name = p[:i]
+			}
+		}
+		g, ok := byName[name]
+		if !ok {
+			g = &splitGroup{Name: name}
+			byName[name] = g
+			groups = append(groups, g)
+		}
+		g.Paths = append(g.Paths, p)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// runCommitSplit commits the staged files group by group, each with its own confirmed message.
+// The index is rebuilt for every group, groups left uncommitted stay staged.
+func runCommitSplit(cco *commitOpt) (err error) {
+	r := cco.Repo
+	if cco.Amend {
+		return errors.New("--split cannot be used with --amend")
+	}
+	if err := tugit.PreCommitHook(r); err != nil {
+		return fmt.Errorf("Error during pre-commit hook: %s", err.Error())
+	}
+
+	m, err := scope.Load(r)
+	if err != nil {
+		return err
+	}
+	diff, err := tugit.StagedDiff(r)
+	if err != nil {
+		return err
+	}
+	paths, err := tugit.DiffFiles(diff)
+	diff.Free()
+	if err != nil {
+		return err
+	}
+	groups := splitStaged(paths, m)
+	hp, err := integrations.NewHeuristicProvider(r)
+	if err != nil {
+		return err
+	}
+
+	staged, err := tugit.RepoTree(r)
+	if err != nil {
+		return err
+	}
+	defer staged.Free()
+	// Whatever happens, stage back what was not committed
+	defer func() {
+		if rerr := tugit.ResetIndex(r, staged); err == nil {
+			err = rerr
+		}
+	}()
+
+	committed := 0
+	for i, g := range groups {
+		if err := tugit.StageOnly(r, staged, g.Paths); err != nil {
+			return err
+		}
+		header, err := splitHeader(r, hp, g, fmt.Sprintf("[%d/%d]", i+1, len(groups)))
+		if err != nil {
+			return err
+		}
+		if header == "" {
+			continue
+		}
+		cmsg, err := buildCommitMessage(header, &commitOpt{Repo: r, PromptEditor: cco.PromptEditor, CoAuthors: cco.CoAuthors, SignOff: cco.SignOff})
+		if err != nil {
+			return err
+		}
+		commit, err := tugit.Commit(r, cmsg)
+		if err != nil {
+			return err
+		}
+		h, err := commit.ShortId()
+		if err != nil {
+			return err
+		}
+		fmt.Println(h, commit.Summary())
+		committed++
+
+		if err := tugit.PostCommitHook(r); err != nil {
+			fmt.Println("Warning, post-commit hook failed:", err.Error())
+		}
+	}
+
+	fmt.Printf("%d commit(s) created, %d group(s) left staged.\n", committed, len(groups)-committed)
+	return nil
+}
+
+// splitHeader prompts for the header of a group, proposing one from the staged diff. An empty header skips the group.
+func splitHeader(r *git.Repository, hp *integrations.HeuristicProvider, g *splitGroup, progress string) (string, error) {
+	diff, err := tugit.StagedDiff(r)
+	if err != nil {
+		return "", err
+	}
+	defer diff.Free()
+	proposal := ""
+	if msgs, err := hp.CommitMessages(diff); err == nil && len(msgs) > 0 {
+		proposal = msgs[0]
+	}
+
+	header := ""
+	prompt := &survey.Input{
+		Message: fmt.Sprintf("%s %s (%s), empty to skip", progress, g.Name, strings.Join(g.Paths, ", ")),
+		Default: proposal,
+	}
+	err = survey.AskOne(prompt, &header, survey.WithValidator(func(ans interface{}) error {
+		if s, _ := ans.(string); s != "" && format.ParseCommitMsg(s) == nil {
+			return errors.New("not a conventional commit header")
+		}
+		return nil
+	}))
+	return header, err
+}
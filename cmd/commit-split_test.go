@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/scope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStaged(t *testing.T) {
+	rule, err := scope.NewRule("pkg/api", "api")
+	require.NoError(t, err)
+	m := scope.Map{rule}
+
+	groups := splitStaged([]string{"pkg/api/server.go", "cmd/root.go", "README.md", "pkg/format/format.go", "pkg/api/client.go", "go.mod"}, m)
+	expected := []*splitGroup{
+		{Name: ".", Paths: []string{"README.md", "go.mod"}},
+		{Name: "api", Paths: []string{"pkg/api/server.go", "pkg/api/client.go"}},
+		{Name: "cmd", Paths: []string{"cmd/root.go"}},
+		{Name: "pkg", Paths: []string{"pkg/format/format.go"}},
+	}
+	assert.Equal(t, expected, groups)
+
+	assert.Empty(t, splitStaged(nil, m))
+}
@@ -22,14 +22,18 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/b4nst/turbogit/internal/cmdbuilder"
 	"github.com/b4nst/turbogit/pkg/format"
 	tugit "github.com/b4nst/turbogit/pkg/git"
 	"github.com/b4nst/turbogit/pkg/integrations"
+	"github.com/b4nst/turbogit/pkg/scope"
 	"github.com/ktr0731/go-fuzzyfinder"
 	git "github.com/libgit2/git2go/v33"
 	"github.com/spf13/cobra"
@@ -44,9 +48,13 @@ func init() {
 	CommitCmd.RegisterFlagCompletionFunc("type", typeFlagCompletion)
 	CommitCmd.Flags().BoolP("breaking-changes", "c", false, "Commit contains breaking changes")
 	CommitCmd.Flags().BoolP("edit", "e", false, "Prompt editor to edit your message (add body or/and footer(s))")
-	CommitCmd.Flags().StringP("scope", "s", "", "Add a scope")
+	CommitCmd.Flags().StringP("scope", "s", "", "Add a scope (default to the scope of the staged files in the tug.scope.* map)")
 	CommitCmd.Flags().BoolP("amend", "a", false, "Amend commit")
 	CommitCmd.Flags().BoolP("fill", "f", false, "Use commit message provider to fill the message")
+	CommitCmd.Flags().Bool("split", false, "Split the staged files by scope or directory into several commits")
+	CommitCmd.Flags().Bool("reuse-draft", false, "Reopen the message of the last failed commit on the current branch")
+	CommitCmd.Flags().BoolP("signoff", "S", false, "Add a Signed-off-by footer with the commit signature (always on if tug.signoff is set)")
+	CommitCmd.Flags().Bool("pair", false, "Pick co-authors from the roster (tug.coauthor, default to the repository authors) to credit in Co-authored-by footers")
 }
 
 func typeFlagCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -72,6 +80,18 @@ $ tug commit ci -e message
 
 # Ammend last commit type
 $ tug commit -a -t fix
+
+# Commit the staged files of each scope separately
+$ tug commit --split
+
+# Edit again the message of a commit rejected by the commit-msg hook
+$ tug commit --reuse-draft
+
+# Certify the Developer Certificate of Origin (Signed-off-by: Alice <alice@example.com>)
+$ tug commit fix -S a bug
+
+# Credit the co-authors of a pairing session
+$ tug commit feat pairing session --pair
 	`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		// TODO: better implementation
@@ -116,6 +136,16 @@ type commitOpt struct {
 	Repo *git.Repository
 	// Use provider to fill
 	Fill bool
+	// Split the staged files into several commits
+	Split bool
+	// Start from the draft of the last failed commit
+	ReuseDraft bool
+	// Pick co-authors from the roster
+	Pair bool
+	// Co-authors credited in the message, as 'name <email>'
+	CoAuthors []string
+	// Add a Signed-off-by footer
+	SignOff bool
 }
 
 func parseCommitCmd(cmd *cobra.Command, args []string) (*commitOpt, error) {
@@ -166,9 +196,45 @@ func parseCommitCmd(cmd *cobra.Command, args []string) (*commitOpt, error) {
 		return nil, err
 	}
 
+	// --split
+	opt.Split, err = cmd.Flags().GetBool("split")
+	if err != nil {
+		return nil, err
+	}
+
+	// --reuse-draft
+	opt.ReuseDraft, err = cmd.Flags().GetBool("reuse-draft")
+	if err != nil {
+		return nil, err
+	}
+	if opt.ReuseDraft {
+		// A draft is always reopened
+		opt.PromptEditor = true
+	}
+
+	// --pair
+	opt.Pair, err = cmd.Flags().GetBool("pair")
+	if err != nil {
+		return nil, err
+	}
+
 	// Find repo
 	opt.Repo = cmdbuilder.GetRepo(cmd)
 
+	// --signoff
+	opt.SignOff, err = cmd.Flags().GetBool("signoff")
+	if err != nil {
+		return nil, err
+	}
+	if !opt.SignOff {
+		c, err := opt.Repo.Config()
+		if err != nil {
+			return nil, err
+		}
+		opt.SignOff, _ = c.LookupBool("tug.signoff")
+		c.Free()
+	}
+
 	opt.Message = strings.Join(args, " ")
 
 	return opt, nil
@@ -176,23 +242,111 @@ func parseCommitCmd(cmd *cobra.Command, args []string) (*commitOpt, error) {
 
 func runCommit(cco *commitOpt) (err error) {
 	// Sanity checks
-	if nc, err := tugit.StageReady(cco.Repo); !cco.Amend && !nc {
+	// A merge may be concluded without changes
+	if nc, err := tugit.StageReady(cco.Repo); !cco.Amend && !nc && cco.Repo.State() != git.RepositoryStateMerge {
 		if err == nil {
 			err = fmt.Errorf("Nothing to commit.")
 		}
 		return err
 	}
+	if cco.Pair {
+		if cco.CoAuthors, err = pickCoAuthors(cco.Repo); err != nil {
+			return err
+		}
+	}
+	if cco.Split {
+		return runCommitSplit(cco)
+	}
 	// Get initial message and commit, if any
+	var commit *git.Commit
 	mi := getMsgInitializer(cco)
 	initMsg, initCommit, err := mi(cco.Repo)
 	if err != nil {
 		return fmt.Errorf("Couldn't retrieve initial message: %w", err)
 	}
-	// Parse initial message
-	cmo := format.ParseCommitMsg(initMsg)
+	// Infer scope from the staged files
+	if !cco.Amend && !cco.ReuseDraft && cco.Scope == "" && cco.Repo.State() == git.RepositoryStateNone {
+		if cmo := format.ParseCommitMsg(initMsg); cmo == nil || cmo.Scope == "" {
+			if cco.Scope, err = stagedScope(cco.Repo); err != nil {
+				return err
+			}
+		}
+	}
+	cmsg, err := buildCommitMessage(initMsg, cco)
+	if err == nil {
+		// Write commit
+		if cco.Amend {
+			commit, err = tugit.Amend(initCommit, cmsg)
+		} else {
+			commit, err = tugit.Commit(cco.Repo, cmsg)
+		}
+	}
+	if err != nil {
+		if cmsg != "" {
+			if derr := saveDraft(cco.Repo, cmsg); derr != nil {
+				fmt.Fprintln(os.Stderr, "Warning, could not save the message draft:", derr.Error())
+			} else {
+				fmt.Fprintln(os.Stderr, "Message saved, run 'tug commit --reuse-draft' to edit it again.")
+			}
+		}
+		return err
+	}
+	if err := cleanDrafts(cco.Repo); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning, could not clean message drafts:", err.Error())
+	}
+
+	h, err := commit.ShortId()
+	if err != nil {
+		return err
+	}
+	fmt.Println(h, commit.Summary())
+
+	err = tugit.PostCommitHook(cco.Repo)
+	if err != nil {
+		fmt.Println("Warning, post-commit hook failed:", err.Error())
+	}
+
+	return nil
+}
+
+// buildCommitMessage applies the type, scope, breaking change, description and editor options to an initial message,
+// then runs the commit-msg hook. A reused draft is reopened in the editor as written.
+// On failure, it also returns the last built message, if any, so that it can be saved as draft.
+func buildCommitMessage(initMsg string, cco *commitOpt) (string, error) {
+	cmsg := initMsg
+	if !cco.ReuseDraft {
+		var err error
+		if cmsg, err = applyCommitOptions(initMsg, cco); err != nil {
+			return cmsg, err
+		}
+	}
+	if cco.PromptEditor {
+		// The editor lints the message, a reused draft included
+		edited, err := editCommitMessage(cco.Repo, cmsg)
+		if err != nil {
+			if edited != "" {
+				return edited, err
+			}
+			return cmsg, err
+		}
+		cmsg = edited
+	}
+	hmsg, err := tugit.CommitMsgHook(cco.Repo, cmsg)
+	if err != nil {
+		return cmsg, fmt.Errorf("Error during commit-msg hook: %s", err.Error())
+	}
+	return hmsg, nil
+}
+
+// applyCommitOptions rebuilds the header of the initial message with the type, scope, breaking change and description options,
+// and appends the co-authors and sign-off footers. The body and footers are kept as written.
+func applyCommitOptions(initMsg string, cco *commitOpt) (string, error) {
+	// Parse initial header
+	header, rest := format.SplitHeader(initMsg)
+	cmo := format.ParseCommitMsg(header)
 	if cmo == nil {
-		// If not formatted put raw message as Description
-		cmo = &format.CommitMessageOption{Description: initMsg}
+		// If not formatted put raw header as Description
+		cmo = &format.CommitMessageOption{Description: header}
 	}
 	// Overwrite with arguments
 	if err := cmo.Overwrite(&format.CommitMessageOption{
@@ -201,45 +355,65 @@ func runCommit(cco *commitOpt) (err error) {
 		Description:     cco.Message,
 		Scope:           cco.Scope,
 	}); err != nil {
-		return err
-	}
-	// Check commit message conformity
-	if err := cmo.Check(); err != nil {
-		return err
+		// Save the message as it stands, the initial one would lose the amended text
+		return format.CommitMessage(cmo) + rest, err
 	}
 	// Build commit message
-	cmsg := format.CommitMessage(cmo)
-	if cco.PromptEditor {
-		cmsg = promptEditor(cmsg)
+	cmsg := format.CommitMessage(cmo) + rest
+	var footers []string
+	for _, a := range cco.CoAuthors {
+		footers = append(footers, coAuthorFooter(a))
 	}
-	cmsg, err = tugit.CommitMsgHook(cco.Repo.Path(), cmsg)
-	if err != nil {
-		return fmt.Errorf("Error during commit-msg hook: %s", err.Error())
+	if cco.SignOff {
+		footer, err := signOffFooter(cco.Repo)
+		if err != nil {
+			return cmsg, err
+		}
+		footers = append(footers, footer)
 	}
+	cmsg = format.AppendFooters(cmsg, footers...)
+	// Check commit message conformity
+	return cmsg, cmo.Check()
+}
 
-	// Write commit
-	var commit *git.Commit
-	if cco.Amend {
-		commit, err = tugit.Amend(initCommit, cmsg)
-	} else {
-		commit, err = tugit.Commit(cco.Repo, cmsg)
+// stagedScope returns the scope of the staged files according to the scope map.
+// If they span several scopes, it warns and prompts for one of them.
+func stagedScope(r *git.Repository) (string, error) {
+	m, err := scope.Load(r)
+	if err != nil || len(m) == 0 {
+		return "", err
 	}
+	diff, err := tugit.StagedDiff(r)
 	if err != nil {
-		return err
+		return "", err
 	}
-
-	h, err := commit.ShortId()
+	defer diff.Free()
+	files, err := tugit.DiffFiles(diff)
 	if err != nil {
-		return err
+		return "", err
 	}
-	fmt.Println(h, commit.Summary())
 
-	err = tugit.PostCommitHook(cco.Repo.Path())
-	if err != nil {
-		fmt.Println("Warning, post-commit hook failed:", err.Error())
+	scopes := m.Scopes(files)
+	switch len(scopes) {
+	case 0:
+		return "", nil
+	case 1:
+		return scopes[0], nil
 	}
-
-	return nil
+	fmt.Fprintf(os.Stderr, "Warning, staged files span several scopes: %s (use --split to commit them separately).\n", strings.Join(scopes, ", "))
+	choice := ""
+	prompt := &survey.Select{Message: "Scope", Options: append(scopes, NO_SCOPE)}
+	if err := survey.AskOne(prompt, &choice); err != nil {
+		if err == terminal.InterruptErr {
+			return "", err
+		}
+		// Not interactive, commit without scope
+		return "", nil
+	}
+	if choice == NO_SCOPE {
+		return "", nil
+	}
+	return choice, nil
 }
 
 type msgInitializer func(*git.Repository) (string, *git.Commit, error)
@@ -247,6 +421,8 @@ type msgInitializer func(*git.Repository) (string, *git.Commit, error)
 func getMsgInitializer(cco *commitOpt) msgInitializer {
 	if cco.Amend {
 		return fromLastCommit
+	} else if cco.ReuseDraft {
+		return fromDraft
 	} else if cco.Fill {
 		return fromProvider
 	} else {
@@ -278,13 +454,18 @@ func fromProvider(r *git.Repository) (string, *git.Commit, error) {
 	}
 	var msgs []string
 	for _, p := range providers {
+		// A failing provider (offline, misconfigured) must not hide the proposals of the others
 		pmsgs, err := p.CommitMessages(diff)
 		if err != nil {
-			return "", nil, err
+			fmt.Fprintln(os.Stderr, "Warning, a commit message provider failed:", err.Error())
+			continue
 		}
 		msgs = append(msgs, pmsgs...)
 	}
 
+	if len(msgs) == 0 {
+		return "", nil, errors.New("No commit message proposed, configure a provider or work on a type/[prefix/]description branch")
+	}
 	if len(msgs) > 1 {
 		idx, err := fuzzyfinder.Find(msgs,
 			func(i int) string {
@@ -307,24 +488,19 @@ func fromProvider(r *git.Repository) (string, *git.Commit, error) {
 }
 
 func fromHooks(r *git.Repository) (string, *git.Commit, error) {
-	if err := tugit.PreCommitHook(r.Path()); err != nil {
+	if err := tugit.PreCommitHook(r); err != nil {
 		return "", nil, fmt.Errorf("Error during pre-commit hook: %s", err.Error())
 	}
-	m, err := tugit.PrepareCommitMsgHook(r.Path())
+	m, err := tugit.PrepareCommitMsgHook(r)
 	if err != nil {
 		return "", nil, fmt.Errorf("Error during prepare-commit-msg hook: %s", err.Error())
 	}
+	if m == "" {
+		// Conclude the merge, cherry-pick or revert in progress
+		if m, err = tugit.StateMessage(r); err != nil {
+			return "", nil, err
+		}
+	}
 
 	return m, nil, nil
 }
-
-func promptEditor(msg string) string {
-	prompt := &survey.Editor{
-		Message:       "Edit commit message",
-		Default:       msg,
-		AppendDefault: true,
-		FileName:      "COMMIT_EDITMSG*",
-	}
-	survey.AskOne(prompt, &msg)
-	return msg
-}
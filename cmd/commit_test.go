@@ -45,6 +45,10 @@ func TestParseCommitCmd(t *testing.T) {
 	cmd.Flags().StringP("scope", "s", "scope", "")
 	cmd.Flags().BoolP("amend", "a", true, "")
 	cmd.Flags().BoolP("fill", "f", true, "")
+	cmd.Flags().Bool("split", true, "")
+	cmd.Flags().Bool("reuse-draft", true, "")
+	cmd.Flags().Bool("pair", true, "")
+	cmd.Flags().BoolP("signoff", "S", true, "")
 
 	cmdbuilder.MockRepoAware(cmd, r)
 
@@ -59,6 +63,31 @@ func TestParseCommitCmd(t *testing.T) {
 		Amend:           true,
 		Repo:            r,
 		Fill:            true,
+		Split:           true,
+		ReuseDraft:      true,
+		Pair:            true,
+		SignOff:         true,
 	}
 	assert.Equal(t, expect, *cco)
 }
+
+func TestCommitTrailers(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	test.StageNewFile(t, r)
+
+	require.NoError(t, runCommit(&commitOpt{
+		CType:     format.FeatureCommit,
+		Message:   "pair",
+		CoAuthors: []string{"Bob <bob@example.com>"},
+		SignOff:   true,
+		Repo:      r,
+	}))
+	head, err := r.Head()
+	require.NoError(t, err)
+	defer head.Free()
+	c, err := r.LookupCommit(head.Target())
+	require.NoError(t, err)
+	assert.Equal(t, "feat: pair\n\nCo-authored-by: Bob <bob@example.com>\nSigned-off-by: "+test.GIT_USERNAME+" <"+test.GIT_EMAIL+">", c.Message())
+}
@@ -0,0 +1,308 @@
+/*
+Copyright © 2022 banst
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/b4nst/turbogit/internal/cmdbuilder"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Marks the hooks written by tug
+	HOOK_MARKER = "# Managed by tug, run 'tug hooks uninstall' to remove."
+	// Suffix of the existing hooks chained by tug hooks
+	HOOK_CHAINED_SUFFIX = ".tug-chained"
+)
+
+// Hooks managed by tug
+var managedHooks = []string{"commit-msg", "prepare-commit-msg"}
+
+// Merge commit headers generated by git
+var mergeHeader = regexp.MustCompile(`^Merge (?:branch|remote-tracking branch|tag|pull request|commit) `)
+
+func init() {
+	RootCmd.AddCommand(HooksCmd)
+	HooksCmd.AddCommand(hooksInstallCmd, hooksUninstallCmd, hooksStatusCmd, hooksRunCmd)
+
+	for _, cmd := range HooksCmd.Commands() {
+		cmdbuilder.RepoAware(cmd)
+	}
+}
+
+// HooksCmd represents the hooks command
+var HooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage the git hooks making git commit conventional-aware.",
+	Long: `
+Install commit-msg and prepare-commit-msg hooks, so that plain git commit is conventional-aware.
+The commit-msg hook rejects non conventional messages (strictly if tug.strict is set),
+the prepare-commit-msg hook pre-fills the type and the issue footer from the current branch.
+Existing hooks are kept and run first.
+`,
+	Example: `
+# Install the hooks
+$ tug hooks install
+
+# Show the hooks status
+$ tug hooks status
+`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install tug hooks.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := tugit.HooksDir(cmdbuilder.GetRepo(cmd))
+		cobra.CheckErr(err)
+		for _, hook := range managedHooks {
+			status, err := installHook(dir, hook)
+			cobra.CheckErr(err)
+			fmt.Printf("%s: %s\n", hook, status)
+		}
+	},
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Uninstall tug hooks, restoring the chained ones.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := tugit.HooksDir(cmdbuilder.GetRepo(cmd))
+		cobra.CheckErr(err)
+		for _, hook := range managedHooks {
+			status, err := uninstallHook(dir, hook)
+			cobra.CheckErr(err)
+			fmt.Printf("%s: %s\n", hook, status)
+		}
+	},
+}
+
+var hooksStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show tug hooks status.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := tugit.HooksDir(cmdbuilder.GetRepo(cmd))
+		cobra.CheckErr(err)
+		fmt.Println("Hooks directory:", dir)
+		for _, hook := range managedHooks {
+			status, err := hookStatus(dir, hook)
+			cobra.CheckErr(err)
+			fmt.Printf("%s: %s\n", hook, status)
+		}
+	},
+}
+
+var hooksRunCmd = &cobra.Command{
+	Use:    "run <hook> <file> [args...]",
+	Short:  "Run a tug hook, called by the installed hooks.",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		r := cmdbuilder.GetRepo(cmd)
+		switch args[0] {
+		case "commit-msg":
+			cobra.CheckErr(runCommitMsgHook(r, args[1]))
+		case "prepare-commit-msg":
+			source := ""
+			if len(args) > 2 {
+				source = args[2]
+			}
+			cobra.CheckErr(runPrepareCommitMsgHook(r, args[1], source))
+		default:
+			cobra.CheckErr(fmt.Errorf("unknown hook '%s'", args[0]))
+		}
+	},
+}
+
+// hookScript returns the script of a tug hook, running the chained hook first.
+func hookScript(hook string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s
+chained="$(dirname "$0")/%s%s"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+exec %s hooks run %s "$@"
+`, HOOK_MARKER, hook, HOOK_CHAINED_SUFFIX, BIN_NAME, hook)
+}
+
+// isManagedHook returns true if the hook at path was written by tug.
+func isManagedHook(path string) (bool, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(content), HOOK_MARKER), nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func installHook(dir string, hook string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, hook)
+	chained := path + HOOK_CHAINED_SUFFIX
+	status := "installed"
+	if exists(path) {
+		managed, err := isManagedHook(path)
+		if err != nil {
+			return "", err
+		}
+		if !managed {
+			if exists(chained) {
+				return "", fmt.Errorf("both %s and %s exist, move one of them first", path, chained)
+			}
+			if err := os.Rename(path, chained); err != nil {
+				return "", err
+			}
+			status = fmt.Sprintf("installed, chaining the existing hook moved to %s", chained)
+		} else {
+			status = "updated"
+		}
+	}
+	return status, ioutil.WriteFile(path, []byte(hookScript(hook)), 0755)
+}
+
+func uninstallHook(dir string, hook string) (string, error) {
+	path := filepath.Join(dir, hook)
+	chained := path + HOOK_CHAINED_SUFFIX
+	if !exists(path) {
+		return "not installed", nil
+	}
+	managed, err := isManagedHook(path)
+	if err != nil {
+		return "", err
+	}
+	if !managed {
+		return "not installed, existing hook left untouched", nil
+	}
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	if exists(chained) {
+		if err := os.Rename(chained, path); err != nil {
+			return "", err
+		}
+		return "uninstalled, chained hook restored", nil
+	}
+	return "uninstalled", nil
+}
+
+func hookStatus(dir string, hook string) (string, error) {
+	path := filepath.Join(dir, hook)
+	if !exists(path) {
+		return "not installed", nil
+	}
+	managed, err := isManagedHook(path)
+	if err != nil {
+		return "", err
+	}
+	if !managed {
+		return "not installed, another hook is present", nil
+	}
+	if chained := path + HOOK_CHAINED_SUFFIX; exists(chained) {
+		return fmt.Sprintf("installed, chaining %s", chained), nil
+	}
+	return "installed", nil
+}
+
+// runCommitMsgHook validates the commit message file.
+func runCommitMsgHook(r *git.Repository, file string) error {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	msg := stripComments(string(raw))
+	header := strings.SplitN(msg, "\n", 2)[0]
+	// Empty messages are rejected by git itself
+	if msg == "" || mergeHeader.MatchString(header) || generatedHeader.MatchString(header) {
+		return nil
+	}
+
+	return lintCommitMessage(r, msg)
+}
+
+// runPrepareCommitMsgHook pre-fills the commit message file from the current branch.
+// source is the source of the message given by git, the message is only pre-filled when there is none.
+func runPrepareCommitMsgHook(r *git.Repository, file string, source string) error {
+	if source != "" && source != "template" {
+		return nil
+	}
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if stripComments(string(raw)) != "" {
+		return nil
+	}
+	prefill := branchPrefill(r)
+	if prefill == "" {
+		return nil
+	}
+	return ioutil.WriteFile(file, []byte(prefill+"\n"+string(raw)), 0644)
+}
+
+// branchPrefill returns the message header and footers derived from the current branch, if any.
+func branchPrefill(r *git.Repository) string {
+	head, err := r.Head()
+	if err != nil || !head.IsBranch() {
+		return ""
+	}
+	defer head.Free()
+	tb, err := format.ParseBranch(head.Shorthand())
+	if err != nil {
+		return ""
+	}
+
+	header := ""
+	// A header without description would not parse, the branch description is the default one
+	if ctype := format.FindCommitType(tb.WithType(tb.Type, format.DefaultTypeRewrite).Type); ctype != format.NilCommit && tb.Description != "" {
+		header = format.CommitMessage(&format.CommitMessageOption{Ctype: ctype, Description: tb.Description})
+	}
+	footer := ""
+	if tb.Prefix != "" && format.DefaultBranchPrefix.MatchString(tb.Prefix) {
+		footer = "Refs: " + tb.Prefix
+	}
+	if header == "" && footer == "" {
+		return ""
+	}
+	if footer == "" {
+		return header
+	}
+	return header + "\n\n" + footer
+}
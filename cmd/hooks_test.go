@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "turbogit-test-hooks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Existing hook
+	existing := filepath.Join(dir, "commit-msg")
+	require.NoError(t, ioutil.WriteFile(existing, []byte("#!/bin/sh\nexit 0\n"), 0755))
+
+	status, err := hookStatus(dir, "commit-msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "not installed, another hook is present", status)
+
+	status, err = installHook(dir, "commit-msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "installed, chaining the existing hook moved to "+existing+HOOK_CHAINED_SUFFIX, status)
+	status, err = installHook(dir, "prepare-commit-msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "installed", status)
+	status, err = installHook(dir, "prepare-commit-msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", status)
+
+	content, err := ioutil.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, hookScript("commit-msg"), string(content))
+	status, err = hookStatus(dir, "commit-msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "installed, chaining "+existing+HOOK_CHAINED_SUFFIX, status)
+
+	status, err = uninstallHook(dir, "commit-msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "uninstalled, chained hook restored", status)
+	content, err = ioutil.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\nexit 0\n", string(content))
+	status, err = uninstallHook(dir, "commit-msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "not installed, existing hook left untouched", status)
+	status, err = uninstallHook(dir, "prepare-commit-msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "uninstalled", status)
+}
+
+func TestRunCommitMsgHook(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	file := filepath.Join(r.Path(), "COMMIT_EDITMSG")
+	tcs := map[string]struct {
+		msg string
+		err string
+	}{
+		"Valid":    {"feat: a feature\n# comment\n", ""},
+		"Merge":    {"Merge branch 'foo'\n", ""},
+		"Fixup":    {"fixup! feat: a feature\n", ""},
+		"Empty":    {"# only comments\n", ""},
+		"Invalid":  {"bad commit\n", "'bad commit' does not follow conventional commit (<type>[(<scope>)][!]: <description>)"},
+		"No type":  {"foo: bar\n", "A commit type is required"},
+		"Scissors": {"bad commit\n# ------------------------ >8 ------------------------\nfeat: diff\n", "'bad commit' does not follow conventional commit (<type>[(<scope>)][!]: <description>)"},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, ioutil.WriteFile(file, []byte(tc.msg), 0644))
+			err := runCommitMsgHook(r, file)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+
+	// Strict mode
+	c, err := r.Config()
+	require.NoError(t, err)
+	require.NoError(t, c.SetBool("tug.strict", true))
+	require.NoError(t, ioutil.WriteFile(file, []byte("feat(): x\n"), 0644))
+	assert.Error(t, runCommitMsgHook(r, file))
+}
+
+func TestRunPrepareCommitMsgHook(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	c, err := tugit.Commit(r, "feat: initial commit")
+	require.NoError(t, err)
+	b, err := r.CreateBranch("feature/PROJ-12/login-timeout", c, false)
+	require.NoError(t, err)
+	require.NoError(t, r.SetHead(b.Reference.Name()))
+
+	file := filepath.Join(r.Path(), "COMMIT_EDITMSG")
+	require.NoError(t, ioutil.WriteFile(file, []byte("# Please enter the commit message\n"), 0644))
+	require.NoError(t, runPrepareCommitMsgHook(r, file, ""))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: Login timeout\n\nRefs: PROJ-12\n# Please enter the commit message\n", string(content))
+
+	// Message given with -m
+	require.NoError(t, ioutil.WriteFile(file, []byte("my message\n"), 0644))
+	require.NoError(t, runPrepareCommitMsgHook(r, file, "message"))
+	content, err = ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "my message\n", string(content))
+}
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/b4nst/turbogit/pkg/filter"
 	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/scope"
 	git "github.com/libgit2/git2go/v33"
 )
 
@@ -21,27 +25,58 @@ func ApplyFilters(c *git.Commit, co *format.CommitMessageOption, filters ...LogF
 	return true, true
 }
 
-func Since(since *time.Time) LogFilter {
+// DateField selects which commit date is used by date filters.
+type DateField int
+
+const (
+	CommitterDate DateField = iota
+	AuthorDate
+)
+
+// ParseDateField parses 'committer' or 'author' into a DateField.
+func ParseDateField(s string) (DateField, error) {
+	switch s {
+	case "", "committer":
+		return CommitterDate, nil
+	case "author":
+		return AuthorDate, nil
+	default:
+		return CommitterDate, fmt.Errorf("unknown date field '%s', expected committer or author", s)
+	}
+}
+
+// Of returns the date of the commit.
+func (df DateField) Of(c *git.Commit) time.Time {
+	if df == AuthorDate {
+		return c.Author().When
+	}
+	return c.Committer().When
+}
+
+// Since keeps commits more recent than since. If ordered is true, commits are expected to come
+// from the newest to the oldest and the walk stops on the first older commit.
+func Since(since *time.Time, field DateField, ordered bool) LogFilter {
 	if since == nil {
 		return PassThru
 	}
 
 	return func(c *git.Commit, co *format.CommitMessageOption) (keep, walk bool) {
-		d := c.Committer().When
+		d := field.Of(c)
 		if d.Before(*since) {
-			return false, false
+			return false, !ordered
 		}
 		return true, true
 	}
 }
 
-func Until(until *time.Time) LogFilter {
+// Until keeps commits older than until.
+func Until(until *time.Time, field DateField) LogFilter {
 	if until == nil {
 		return PassThru
 	}
 
 	return func(c *git.Commit, co *format.CommitMessageOption) (keep, walk bool) {
-		d := c.Committer().When
+		d := field.Of(c)
 		if d.After(*until) {
 			return false, true
 		}
@@ -69,7 +104,9 @@ func Type(types []format.CommitType) LogFilter {
 	}
 }
 
-func Scope(scopes []string) LogFilter {
+// Scope keeps commits with one of the scopes. Commits without scope are kept if they touch a path
+// the scope map links to one of the scopes.
+func Scope(scopes []string, m scope.Map) LogFilter {
 	if len(scopes) <= 0 {
 		return PassThru
 	}
@@ -79,6 +116,111 @@ func Scope(scopes []string) LogFilter {
 		ms[s] = true
 	}
 	return func(c *git.Commit, co *format.CommitMessageOption) (keep, walk bool) {
-		return ms[co.Scope], true
+		if co.Scope != "" || len(m) == 0 {
+			return ms[co.Scope], true
+		}
+		files, err := tugit.CommitFiles(c)
+		if err != nil {
+			return false, true
+		}
+		for _, s := range m.Scopes(files) {
+			if ms[s] {
+				return true, true
+			}
+		}
+		return false, true
+	}
+}
+
+// NoMerges drops merge commits.
+func NoMerges(enabled bool) LogFilter {
+	if !enabled {
+		return PassThru
+	}
+
+	return func(c *git.Commit, co *format.CommitMessageOption) (keep, walk bool) {
+		return c.ParentCount() <= 1, true
+	}
+}
+
+// OnlyMerges keeps merge commits only.
+func OnlyMerges(enabled bool) LogFilter {
+	if !enabled {
+		return PassThru
+	}
+
+	return func(c *git.Commit, co *format.CommitMessageOption) (keep, walk bool) {
+		return c.ParentCount() > 1, true
+	}
+}
+
+// MaxCount stops the walk after max commits were kept. It counts every commit it sees,
+// so it must be the last filter applied.
+func MaxCount(max int) LogFilter {
+	if max <= 0 {
+		return PassThru
+	}
+
+	count := 0
+	return func(c *git.Commit, co *format.CommitMessageOption) (keep, walk bool) {
+		count++
+		return count <= max, count < max
+	}
+}
+
+// Where keeps the commits matching a filter expression. The expression date field refers to the given commit date.
+func Where(expr *filter.Expression, field DateField) LogFilter {
+	if expr == nil {
+		return PassThru
+	}
+
+	return func(c *git.Commit, co *format.CommitMessageOption) (keep, walk bool) {
+		return expr.Match(&commitSubject{c: c, co: co, dateField: field}), true
+	}
+}
+
+// commitSubject exposes a commit to filter expressions.
+type commitSubject struct {
+	c         *git.Commit
+	co        *format.CommitMessageOption
+	dateField DateField
+	files     []string
+}
+
+func (cs *commitSubject) Type() string {
+	return cs.co.Ctype.String()
+}
+
+func (cs *commitSubject) Scope() string {
+	return cs.co.Scope
+}
+
+func (cs *commitSubject) Breaking() bool {
+	return cs.co.BreakingChanges
+}
+
+func (cs *commitSubject) Authors() []string {
+	a := cs.c.Author()
+	authors := []string{a.Name, a.Email}
+	for _, ident := range cs.co.CoAuthors() {
+		name, email := format.ParseIdent(ident)
+		authors = append(authors, name, email)
+	}
+	return authors
+}
+
+func (cs *commitSubject) Date() time.Time {
+	return cs.dateField.Of(cs.c)
+}
+
+func (cs *commitSubject) Footers(key string) []string {
+	return cs.co.FooterValues(key)
+}
+
+func (cs *commitSubject) Files() []string {
+	if cs.files == nil {
+		// Files are only computed when the expression needs them
+		cs.files, _ = tugit.CommitFiles(cs.c)
 	}
+	return cs.files
 }
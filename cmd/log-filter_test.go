@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/b4nst/turbogit/pkg/filter"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/scope"
+	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateFilters(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	tree, err := tugit.RepoTree(r)
+	require.NoError(t, err)
+	author := &git.Signature{Name: test.GIT_USERNAME, Email: test.GIT_EMAIL, When: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	committer := &git.Signature{Name: test.GIT_USERNAME, Email: test.GIT_EMAIL, When: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	oid, err := r.CreateCommit("HEAD", author, committer, "feat: rebased", tree)
+	require.NoError(t, err)
+	c, err := r.LookupCommit(oid)
+	require.NoError(t, err)
+
+	since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	co := &format.CommitMessageOption{}
+	tcs := map[string]struct {
+		filter LogFilter
+		keep   bool
+		walk   bool
+	}{
+		"Committer date":         {Since(&since, CommitterDate, true), true, true},
+		"Author date unordered":  {Since(&since, AuthorDate, false), false, true},
+		"Author date ordered":    {Since(&since, AuthorDate, true), false, false},
+		"Nil date":               {Since(nil, AuthorDate, true), true, true},
+		"Until committer date":   {Until(&since, CommitterDate), false, true},
+		"Until author date":      {Until(&since, AuthorDate), true, true},
+		"Max count":              {MaxCount(1), true, false},
+		"No merges":              {NoMerges(true), true, true},
+		"Only merges":            {OnlyMerges(true), false, true},
+		"Only merges (disabled)": {OnlyMerges(false), true, true},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			keep, walk := tc.filter(c, co)
+			assert.Equal(t, tc.keep, keep)
+			assert.Equal(t, tc.walk, walk)
+		})
+	}
+}
+
+func TestParseDateField(t *testing.T) {
+	df, err := ParseDateField("author")
+	assert.NoError(t, err)
+	assert.Equal(t, AuthorDate, df)
+
+	df, err = ParseDateField("")
+	assert.NoError(t, err)
+	assert.Equal(t, CommitterDate, df)
+
+	_, err = ParseDateField("foo")
+	assert.EqualError(t, err, "unknown date field 'foo', expected committer or author")
+}
+
+func TestScopeFilter(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(r.Workdir(), "api"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(r.Workdir(), "api", "server.go"), []byte("package api\n"), 0644))
+	index, err := r.Index()
+	require.NoError(t, err)
+	defer index.Free()
+	require.NoError(t, index.AddByPath("api/server.go"))
+	require.NoError(t, index.Write())
+	c, err := tugit.Commit(r, "feat: serve")
+	require.NoError(t, err)
+
+	rule, err := scope.NewRule("api", "api")
+	require.NoError(t, err)
+	m := scope.Map{rule}
+
+	tcs := map[string]struct {
+		filter LogFilter
+		co     *format.CommitMessageOption
+		keep   bool
+	}{
+		"No scopes":           {Scope(nil, m), &format.CommitMessageOption{}, true},
+		"Message scope":       {Scope([]string{"cli"}, m), &format.CommitMessageOption{Scope: "cli"}, true},
+		"Other message scope": {Scope([]string{"api"}, m), &format.CommitMessageOption{Scope: "cli"}, false},
+		"Mapped path":         {Scope([]string{"api"}, m), &format.CommitMessageOption{}, true},
+		"Other mapped path":   {Scope([]string{"cli"}, m), &format.CommitMessageOption{}, false},
+		"Mapped path, no map": {Scope([]string{"api"}, nil), &format.CommitMessageOption{}, false},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			keep, walk := tc.filter(c, tc.co)
+			assert.Equal(t, tc.keep, keep)
+			assert.True(t, walk)
+		})
+	}
+}
+
+func TestWhereCoAuthors(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	c, err := tugit.Commit(r, "feat: pair\n\nCo-authored-by: Bob <bob@example.com>")
+	require.NoError(t, err)
+	co := format.ParseCommitMsg(c.Message())
+
+	for expr, keep := range map[string]bool{
+		"author == Alice":             true,
+		`author == "bob@example.com"`: true,
+		"author == Carol":             false,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			e, err := filter.Compile(expr)
+			require.NoError(t, err)
+			got, _ := Where(e, CommitterDate)(c, co)
+			assert.Equal(t, keep, got)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/gdamore/tcell/v2"
+	"github.com/ktr0731/go-fuzzyfinder"
+	git "github.com/libgit2/git2go/v33"
+)
+
+// logAction is an action that can be run on the commit selected in the interactive log.
+type logAction struct {
+	// Key binding
+	Key rune
+	// Action name
+	Name string
+	// Run the action on the selected commit
+	Run func(r *git.Repository, c *git.Commit) error
+}
+
+var logActions = []logAction{
+	{Key: 'c', Name: "checkout", Run: checkoutCommit},
+	{Key: 'f', Name: "create a fixup commit from the staged changes", Run: fixupCommit},
+	{Key: 'r', Name: "revert", Run: revertCommit},
+	{Key: 'p', Name: "cherry-pick onto HEAD", Run: cherryPickCommit},
+	{Key: 'y', Name: "copy hash", Run: copyCommitHash},
+}
+
+// runLogTUI lets the user fuzzy search the commits and run an action on the selected one.
+func runLogTUI(r *git.Repository, commits []*git.Commit) error {
+	if len(commits) <= 0 {
+		return errors.New("No commit to show")
+	}
+
+	entries := make([]string, len(commits))
+	for i, c := range commits {
+		entries[i] = logEntry(c)
+	}
+	var mu sync.Mutex
+	previews := make(map[int]string, len(commits))
+	preview := func(i, _, _ int) string {
+		if i == -1 {
+			return ""
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := previews[i]; !ok {
+			previews[i] = commitPreview(commits[i])
+		}
+		return previews[i]
+	}
+
+	for {
+		idx, err := fuzzyfinder.Find(entries,
+			func(i int) string {
+				return entries[i]
+			},
+			fuzzyfinder.WithHeader("Select a commit to choose an action"),
+			fuzzyfinder.WithPreviewWindow(preview))
+		if errors.Is(err, fuzzyfinder.ErrAbort) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		action, back, err := promptLogAction(entries[idx])
+		if err != nil {
+			return err
+		}
+		if back {
+			continue
+		}
+		if action == nil {
+			return nil
+		}
+		return action.Run(r, commits[idx])
+	}
+}
+
+// logEntry returns the one line representation of a commit.
+func logEntry(c *git.Commit) string {
+	h, err := c.ShortId()
+	if err != nil {
+		h = c.Id().String()
+	}
+	return fmt.Sprintf("%s %s", h, c.Summary())
+}
+
+// commitPreview returns the full commit message followed by its patch.
+func commitPreview(c *git.Commit) string {
+	var sb strings.Builder
+	a := c.Author()
+	fmt.Fprintf(&sb, "commit %s\nAuthor: %s <%s>\nDate:   %s\n\n", c.Id(), a.Name, a.Email, a.When.Format("Mon Jan 2 15:04:05 2006 -0700"))
+	sb.WriteString(c.Message())
+
+	diff, err := tugit.CommitDiff(c)
+	if err != nil {
+		fmt.Fprintf(&sb, "\n\nCould not compute diff: %s", err)
+		return sb.String()
+	}
+	defer diff.Free()
+	patch, err := tugit.PatchFromDiff(diff)
+	if err != nil {
+		fmt.Fprintf(&sb, "\n\nCould not compute patch: %s", err)
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	sb.WriteString(patch)
+	return sb.String()
+}
+
+// promptLogAction displays the key bindings and waits for the user choice.
+// It returns back=true if the user wants to go back to the history, or a nil action if the user quits.
+func promptLogAction(entry string) (action *logAction, back bool, err error) {
+	s, err := tcell.NewScreen()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.Init(); err != nil {
+		return nil, false, err
+	}
+	defer s.Fini()
+
+	draw := func() {
+		s.Clear()
+		drawLine(s, 0, entry, tcell.StyleDefault.Bold(true))
+		for i, a := range logActions {
+			drawLine(s, i+2, fmt.Sprintf("  %c    %s", a.Key, a.Name), tcell.StyleDefault)
+		}
+		drawLine(s, len(logActions)+3, "  esc  back to the history", tcell.StyleDefault.Dim(true))
+		drawLine(s, len(logActions)+4, "  q    quit", tcell.StyleDefault.Dim(true))
+		s.Show()
+	}
+	draw()
+
+	for {
+		switch ev := s.PollEvent().(type) {
+		case *tcell.EventResize:
+			s.Sync()
+			draw()
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyEscape:
+				return nil, true, nil
+			case tcell.KeyCtrlC:
+				return nil, false, nil
+			case tcell.KeyRune:
+				if ev.Rune() == 'q' {
+					return nil, false, nil
+				}
+				for i := range logActions {
+					if logActions[i].Key == ev.Rune() {
+						return &logActions[i], false, nil
+					}
+				}
+			}
+		}
+	}
+}
+
+func drawLine(s tcell.Screen, y int, text string, style tcell.Style) {
+	for x, r := range []rune(text) {
+		s.SetContent(x, y, r, nil, style)
+	}
+}
+
+func checkoutCommit(r *git.Repository, c *git.Commit) error {
+	tree, err := c.Tree()
+	if err != nil {
+		return err
+	}
+	if err := r.CheckoutTree(tree, &git.CheckoutOpts{Strategy: git.CheckoutSafe}); err != nil {
+		return err
+	}
+	if err := r.SetHeadDetached(c.Id()); err != nil {
+		return err
+	}
+	fmt.Println("HEAD is now at", logEntry(c))
+	return nil
+}
+
+func fixupCommit(r *git.Repository, c *git.Commit) error {
+	if ready, err := tugit.StageReady(r); !ready {
+		if err == nil {
+			err = fmt.Errorf("Nothing to commit.")
+		}
+		return err
+	}
+	fc, err := tugit.Commit(r, "fixup! "+c.Summary())
+	if err != nil {
+		return err
+	}
+	fmt.Println(logEntry(fc))
+	return nil
+}
+
+func revertCommit(r *git.Repository, c *git.Commit) error {
+	if err := r.Revert(c, nil); err != nil {
+		return err
+	}
+	fmt.Printf("Changes of %s reverted, review them and run 'tug commit' to record the revert.\n", logEntry(c))
+	return nil
+}
+
+func cherryPickCommit(r *git.Repository, c *git.Commit) error {
+	pc, err := tugit.CherryPick(r, c)
+	if err != nil {
+		return err
+	}
+	fmt.Println(logEntry(pc))
+	return nil
+}
+
+func copyCommitHash(r *git.Repository, c *git.Commit) error {
+	h := c.Id().String()
+	// OSC 52 asks the terminal to copy the text into the system clipboard
+	fmt.Printf("\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(h)))
+	fmt.Println(h)
+	return nil
+}
@@ -25,12 +25,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"text/tabwriter"
 	"time"
 
 	"github.com/araddon/dateparse"
 	"github.com/b4nst/turbogit/internal/cmdbuilder"
+	"github.com/b4nst/turbogit/pkg/filter"
 	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/scope"
 	git "github.com/libgit2/git2go/v33"
 	"github.com/spf13/cobra"
 )
@@ -42,21 +46,51 @@ func init() {
 
 	LogCmd.Flags().BoolP("all", "a", false, "Pretend as if all the refs in refs/, along with HEAD, are listed on the command line as <commit>. If set on true, the --from option will be ignored.")
 	LogCmd.Flags().Bool("no-color", false, "Disable color output")
-	LogCmd.Flags().StringP("from", "f", "HEAD", "Logs only commits reachable from this one")
+	LogCmd.Flags().BoolP("interactive", "i", false, "Browse the history interactively, with a preview of the selected commit and actions on it")
+	LogCmd.Flags().StringP("from", "f", "HEAD", "Logs only commits reachable from this one. Ignored if revision ranges are given.")
+	LogCmd.Flags().Bool("first-parent", false, "Follow only the first parent commit upon seeing a merge commit")
+	LogCmd.Flags().Bool("no-merges", false, "Do not show merge commits")
+	LogCmd.Flags().Bool("merges", false, "Only show merge commits")
+	LogCmd.Flags().IntP("max-count", "n", 0, "Limit the number of commits to output")
 	LogCmd.Flags().String("since", "", "Show commits more recent than a specific date")
 	LogCmd.Flags().String("until", "", "Show commits older than a specific date")
+	LogCmd.Flags().String("date-field", "committer", "Date used by --since and --until (committer or author)")
+	LogCmd.Flags().String("sort", "", fmt.Sprintf("Commit ordering %s", logSortNames()))
+	LogCmd.RegisterFlagCompletionFunc("sort", sortFlagCompletion)
 	// logCmd.Flags().String("path", "", "Filter commits based on the path of files that are updated. Accept regexp")
 	// Filters
 	LogCmd.Flags().StringArrayP("type", "t", []string{}, "Filter commits by type (repeatable option)")
-	LogCmd.Flags().StringArrayP("scope", "s", []string{}, "Filter commits by scope (repeatable option)")
+	LogCmd.Flags().StringArrayP("scope", "s", []string{}, "Filter commits by scope, or touching a path of the scope map when they have none (repeatable option)")
 	LogCmd.Flags().BoolP("breaking-changes", "c", false, "Only shows breaking changes")
+	LogCmd.Flags().StringP("where", "w", "", "Filter commits with an expression (e.g. '(type in (feat, fix)) && scope == api && !(author =~ bot) && footer.Refs')")
 }
 
 // LogCmd represents the log command
 var LogCmd = &cobra.Command{
-	Use:   "logs",
+	Use:   "logs [<revision range>...]",
 	Short: "Shows the commit logs.",
-	Args:  cobra.NoArgs,
+	Long: `
+Revision ranges follow git syntax: 'A..B' shows commits reachable from B but not from A,
+'A...B' shows commits reachable from either A or B but not from both, and '^A' excludes commits reachable from A.
+When no revision is given, commits reachable from --from are shown.
+`,
+	Example: `
+# Shows features and fixes of the api scope that reference an issue
+$ tug logs --where '(type == feat || type == fix) && scope == api && footer.Refs'
+
+# Shows commits touching the docs that were not written by a bot
+$ tug logs --where 'files =~ "^docs/" && !(author =~ bot)'
+
+# Shows everything on the current branch that is not on main yet
+$ tug logs main..HEAD
+
+# Shows the last 10 commits of main, ignoring merged branches
+$ tug logs main --first-parent -n 10
+
+# Browse the features history, then checkout, fixup, revert, cherry-pick or copy the hash of a commit
+$ tug logs -i -t feat
+`,
+	Args: cobra.ArbitraryArgs,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		opt := &logOpt{}
@@ -68,9 +102,26 @@ var LogCmd = &cobra.Command{
 		// --no-color
 		opt.NoColor, err = cmd.Flags().GetBool("no-color")
 		cobra.CheckErr(err)
+		// --interactive
+		opt.Interactive, err = cmd.Flags().GetBool("interactive")
+		cobra.CheckErr(err)
 		// --from
 		opt.From, err = cmd.Flags().GetString("from")
 		cobra.CheckErr(err)
+		// revision ranges
+		opt.Revisions = args
+		// --first-parent
+		opt.FirstParent, err = cmd.Flags().GetBool("first-parent")
+		cobra.CheckErr(err)
+		// --no-merges
+		opt.NoMerges, err = cmd.Flags().GetBool("no-merges")
+		cobra.CheckErr(err)
+		// --merges
+		opt.Merges, err = cmd.Flags().GetBool("merges")
+		cobra.CheckErr(err)
+		// --max-count
+		opt.MaxCount, err = cmd.Flags().GetInt("max-count")
+		cobra.CheckErr(err)
 		// --since
 		fSince, err := cmd.Flags().GetString("since")
 		cobra.CheckErr(err)
@@ -87,6 +138,17 @@ var LogCmd = &cobra.Command{
 			cobra.CheckErr(err)
 			opt.Until = &date
 		}
+		// --date-field
+		fDateField, err := cmd.Flags().GetString("date-field")
+		cobra.CheckErr(err)
+		opt.DateField, err = ParseDateField(fDateField)
+		cobra.CheckErr(err)
+		// --sort
+		opt.Sort, err = cmd.Flags().GetString("sort")
+		cobra.CheckErr(err)
+		if _, ok := logSorts[opt.Sort]; !ok {
+			cobra.CheckErr(fmt.Errorf("unknown sort '%s', expected one of %s", opt.Sort, logSortNames()))
+		}
 		// --types
 		fTypes, err := cmd.Flags().GetStringArray("type")
 		cobra.CheckErr(err)
@@ -100,6 +162,13 @@ var LogCmd = &cobra.Command{
 		// --breaking-changes
 		opt.BreakingChange, err = cmd.Flags().GetBool("breaking-changes")
 		cobra.CheckErr(err)
+		// --where
+		fWhere, err := cmd.Flags().GetString("where")
+		cobra.CheckErr(err)
+		if fWhere != "" {
+			opt.Where, err = filter.Compile(fWhere)
+			cobra.CheckErr(err)
+		}
 
 		opt.Repo = cmdbuilder.GetRepo(cmd)
 
@@ -111,60 +180,159 @@ var LogCmd = &cobra.Command{
 type logOpt struct {
 	All            bool
 	NoColor        bool
+	Interactive    bool
 	From           string
+	Revisions      []string
+	FirstParent    bool
+	NoMerges       bool
+	Merges         bool
+	MaxCount       int
 	Since          *time.Time
 	Until          *time.Time
+	DateField      DateField
+	Sort           string
 	Types          []format.CommitType
 	Scopes         []string
 	BreakingChange bool
+	Where          *filter.Expression
 	Repo           *git.Repository
 }
 
 func runLog(opt *logOpt) error {
 	r := opt.Repo
 
-	walk, err := r.Walk()
+	walk, err := newRevWalk(r, opt.All, opt.From, opt.Revisions, opt.FirstParent)
 	if err != nil {
 		return err
 	}
-	if opt.All {
-		if err := walk.PushGlob("refs/*"); err != nil {
-			return err
-		}
-	} else {
-		from, err := r.RevparseSingle(opt.From)
-		if err != nil {
-			return err
-		}
-		if err := walk.Push(from.Id()); err != nil {
-			return err
-		}
+	defer walk.Free()
+
+	scopes, err := scope.Load(r)
+	if err != nil {
+		return err
 	}
 
 	// Build filters
+	ordered := isDateOrdered(opt.Sort, opt.DateField)
 	filters := []LogFilter{
-		Since(opt.Since),
-		Until(opt.Until),
+		Since(opt.Since, opt.DateField, ordered),
+		Until(opt.Until, opt.DateField),
 		Type(opt.Types),
-		Scope(opt.Scopes),
+		Scope(opt.Scopes, scopes),
 		BreakingChange(opt.BreakingChange),
+		Where(opt.Where, opt.DateField),
+		NoMerges(opt.NoMerges),
+		OnlyMerges(opt.Merges),
+		// Must stay last to only count displayed commits
+		MaxCount(opt.MaxCount),
+	}
+
+	if opt.Interactive {
+		var commits []*git.Commit
+		if err := walkCommits(walk, opt.Sort, collectWalker(&commits, filters)); err != nil {
+			return err
+		}
+		return runLogTUI(r, commits)
 	}
 
 	tw := tabwriter.NewWriter(os.Stdout, 10, 1, 1, ' ', 0)
 	defer tw.Flush()
-	if err := walk.Iterate(buildLogWalker(tw, !opt.NoColor, filters)); err != nil {
+	if err := walkCommits(walk, opt.Sort, buildLogWalker(tw, !opt.NoColor, filters)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func buildLogWalker(w io.Writer, color bool, filters []LogFilter) func(c *git.Commit) bool {
+// newRevWalk creates a revision walker over all the refs or the given revisions.
+// When neither all nor revisions are set, commits reachable from 'from' are walked.
+func newRevWalk(r *git.Repository, all bool, from string, revs []string, firstParent bool) (*git.RevWalk, error) {
+	walk, err := r.Walk()
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		if err := walk.PushGlob("refs/*"); err != nil {
+			walk.Free()
+			return nil, err
+		}
+	} else if len(revs) <= 0 {
+		revs = []string{from}
+	}
+	if err := tugit.PushRevisions(r, walk, revs); err != nil {
+		walk.Free()
+		return nil, err
+	}
+	if firstParent {
+		walk.SimplifyFirstParent()
+	}
+	return walk, nil
+}
+
+// Commit orderings, mapped to the revwalk sorting they rely on
+var logSorts = map[string]git.SortType{
+	"":            git.SortNone,
+	"topo":        git.SortTopological,
+	"date":        git.SortTime,
+	"author-date": git.SortNone,
+	"reverse":     git.SortTopological | git.SortReverse,
+}
+
+func logSortNames() []string {
+	return []string{"topo", "date", "author-date", "reverse"}
+}
+
+func sortFlagCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return logSortNames(), cobra.ShellCompDirectiveDefault
+}
+
+// isDateOrdered returns true if the sort order guarantees that commits come from the newest to the oldest
+// according to the date field, i.e. a date filter can stop the walk early.
+func isDateOrdered(order string, field DateField) bool {
+	return (order == "date" && field == CommitterDate) || (order == "author-date" && field == AuthorDate)
+}
+
+// walkCommits iterates over the walk in the given order.
+func walkCommits(walk *git.RevWalk, order string, fn git.RevWalkIterator) error {
+	walk.Sorting(logSorts[order])
+	if order != "author-date" {
+		return walk.Iterate(fn)
+	}
+
+	// libgit2 can only sort by committer date, author date ordering is done in memory
+	var commits []*git.Commit
+	if err := walk.Iterate(func(c *git.Commit) bool {
+		commits = append(commits, c)
+		return true
+	}); err != nil {
+		return err
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Author().When.After(commits[j].Author().When)
+	})
+	for _, c := range commits {
+		if !fn(c) {
+			break
+		}
+	}
+	return nil
+}
+
+// collectWalker appends the commits passing the filters to commits.
+func collectWalker(commits *[]*git.Commit, filters []LogFilter) git.RevWalkIterator {
 	return func(c *git.Commit) bool {
-		co := format.ParseCommitMsg(c.Message())
-		if co == nil {
-			co = &format.CommitMessageOption{}
+		co := orEmpty(format.ParseCommitMsg(c.Message()))
+		keep, walk := ApplyFilters(c, co, filters...)
+		if keep {
+			*commits = append(*commits, c)
 		}
+		return walk
+	}
+}
+
+func buildLogWalker(w io.Writer, color bool, filters []LogFilter) func(c *git.Commit) bool {
+	return func(c *git.Commit) bool {
+		co := orEmpty(format.ParseCommitMsg(c.Message()))
 		keep, walk := ApplyFilters(c, co, filters...)
 		if !keep {
 			return walk
@@ -0,0 +1,316 @@
+/*
+Copyright © 2022 banst
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/b4nst/turbogit/internal/cmdbuilder"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/integrations"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	RootCmd.AddCommand(MigrateCmd)
+
+	cmdbuilder.RepoAware(MigrateCmd)
+
+	MigrateCmd.Flags().StringP("branch", "b", "", "Branch to create with the rewritten history (default <source>-conventional)")
+	MigrateCmd.Flags().String("base", "", "Only rewrite commits not reachable from this revision")
+	MigrateCmd.Flags().StringP("rules", "r", "", "YAML file of ordered rules, each with a 'match' regular expression and its 'replace' header")
+	MigrateCmd.Flags().BoolP("interactive", "i", false, "Prompt for the message of each non compliant commit")
+	MigrateCmd.Flags().BoolP("fill", "f", false, "Use commit message provider to propose messages")
+	MigrateCmd.Flags().StringP("mapping", "m", "", "File to write the old to new commit mapping to (default .git/tug-migrate-<branch>.map)")
+}
+
+// MigrateCmd represents the migrate command
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate [<source>]",
+	Short: "Rewrite non conventional history onto a new branch.",
+	Long: `
+Rewrite the history of a branch (HEAD by default) onto a new branch, turning non compliant messages into conventional ones.
+Each non compliant header is first matched against the ordered rules, then given to the commit message providers (--fill),
+and finally prompted (--interactive). Compliant commits are kept, trees, authors and dates are preserved.
+
+Rules are a YAML list, the first rule whose expression matches the header wins:
+
+- match: '(?i)^fixe?d? (.*)'
+  replace: 'fix: $1'
+- match: '(?i)^add(?:ed)? (.*)'
+  replace: 'feat: add $1'
+
+The source branch is left untouched. The mapping of every rewritten commit is written as '<old sha> <new sha>' lines.
+`,
+	Example: `
+# Rewrite the current branch onto main-conventional with rules
+$ tug migrate main --rules migration.yaml
+
+# Rewrite the commits of a feature branch, prompting for each message
+$ tug migrate feat/legacy --base main -i -b feat/legacy-conventional
+`,
+	Args: cobra.MaximumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		opt := &migrateOpt{Source: "HEAD"}
+		var err error
+
+		if len(args) > 0 {
+			opt.Source = args[0]
+		}
+		opt.Branch, err = cmd.Flags().GetString("branch")
+		cobra.CheckErr(err)
+		opt.Base, err = cmd.Flags().GetString("base")
+		cobra.CheckErr(err)
+		fRules, err := cmd.Flags().GetString("rules")
+		cobra.CheckErr(err)
+		if fRules != "" {
+			opt.Rules, err = loadMigrateRules(fRules)
+			cobra.CheckErr(err)
+		}
+		opt.Interactive, err = cmd.Flags().GetBool("interactive")
+		cobra.CheckErr(err)
+		opt.Fill, err = cmd.Flags().GetBool("fill")
+		cobra.CheckErr(err)
+		opt.Mapping, err = cmd.Flags().GetString("mapping")
+		cobra.CheckErr(err)
+
+		opt.Repo = cmdbuilder.GetRepo(cmd)
+		if opt.Fill {
+			opt.Commiters, err = integrations.Commiters(opt.Repo)
+			cobra.CheckErr(err)
+		}
+
+		cobra.CheckErr(runMigrate(opt))
+	},
+}
+
+type migrateOpt struct {
+	Source      string
+	Branch      string
+	Base        string
+	Rules       []*migrateRule
+	Interactive bool
+	Fill        bool
+	Commiters   []integrations.Commiter
+	Mapping     string
+	Repo        *git.Repository
+}
+
+// migrateRule rewrites the headers matching an expression.
+type migrateRule struct {
+	Match   *regexp.Regexp
+	Replace string
+}
+
+func (mr *migrateRule) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Match   string `yaml:"match"`
+		Replace string `yaml:"replace"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	if raw.Match == "" || raw.Replace == "" {
+		return fmt.Errorf("line %d: a rule needs a 'match' expression and a 'replace' header", value.Line)
+	}
+	re, err := regexp.Compile(raw.Match)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	mr.Match = re
+	mr.Replace = raw.Replace
+	return nil
+}
+
+func loadMigrateRules(path string) ([]*migrateRule, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []*migrateRule
+	if err := yaml.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+func runMigrate(opt *migrateOpt) error {
+	r := opt.Repo
+	tip, err := tugit.ResolveCommit(r, opt.Source)
+	if err != nil {
+		return err
+	}
+	var base *git.Oid
+	if opt.Base != "" {
+		if base, err = tugit.ResolveCommit(r, opt.Base); err != nil {
+			return err
+		}
+	}
+	if opt.Branch == "" {
+		opt.Branch = migrateBranchName(r, opt.Source)
+	}
+	if _, err := r.LookupBranch(opt.Branch, git.BranchLocal); err == nil {
+		return fmt.Errorf("Branch %s already exists.", opt.Branch)
+	}
+	if opt.Mapping == "" {
+		opt.Mapping = filepath.Join(r.Path(), fmt.Sprintf("tug-migrate-%s.map", strings.ReplaceAll(opt.Branch, "/", "-")))
+	}
+
+	var order []git.Oid
+	rewritten, left := 0, 0
+	newTip, mapping, err := tugit.RewriteHistory(r, tip, base, func(c *git.Commit) (string, error) {
+		order = append(order, *c.Id())
+		msg, err := migrateMessage(c, opt)
+		if err != nil {
+			return "", err
+		}
+		if msg != c.Message() {
+			rewritten++
+		} else if format.ParseCommitMsg(msg) == nil {
+			left++
+		}
+		return msg, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := tugit.ReflogMessage("migrate", fmt.Sprintf("created from %s", opt.Source))
+	if _, err := tugit.CreateBranch(r, opt.Branch, newTip, msg); err != nil {
+		return err
+	}
+	if err := writeMigrateMapping(opt.Mapping, order, mapping); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d commit(s) rewritten, %d left non compliant.\n", rewritten, left)
+	fmt.Printf("Branch %s created, mapping written to %s.\n", opt.Branch, opt.Mapping)
+	return nil
+}
+
+// migrateBranchName returns the default name of the migrated branch.
+func migrateBranchName(r *git.Repository, source string) string {
+	name := source
+	if ref, err := r.References.Dwim(source); err == nil {
+		if target, err := ref.Resolve(); err == nil && target.IsBranch() {
+			name, _ = target.Branch().Name()
+		} else if ref.IsBranch() {
+			name, _ = ref.Branch().Name()
+		}
+	}
+	return name + "-conventional"
+}
+
+// migrateMessage returns the migrated message of a commit, or its message if it is compliant or no new message was found.
+func migrateMessage(c *git.Commit, opt *migrateOpt) (string, error) {
+	msg := c.Message()
+	if format.ParseCommitMsg(msg) != nil {
+		return msg, nil
+	}
+
+	header, err := migrateHeader(c, opt)
+	if err != nil || header == "" {
+		return msg, err
+	}
+	// Keep the original body
+	if i := strings.Index(msg, "\n"); i >= 0 {
+		return header + msg[i:], nil
+	}
+	return header, nil
+}
+
+// migrateHeader returns a conventional header for a non compliant commit, or an empty string.
+func migrateHeader(c *git.Commit, opt *migrateOpt) (string, error) {
+	summary := c.Summary()
+	proposal := ""
+	for _, rule := range opt.Rules {
+		if !rule.Match.MatchString(summary) {
+			continue
+		}
+		if header := rule.Match.ReplaceAllString(summary, rule.Replace); format.ParseCommitMsg(header) != nil {
+			proposal = header
+			break
+		}
+	}
+
+	if proposal == "" && len(opt.Commiters) > 0 {
+		diff, err := tugit.CommitDiff(c)
+		if err != nil {
+			return "", err
+		}
+		defer diff.Free()
+		for _, p := range opt.Commiters {
+			msgs, err := p.CommitMessages(diff)
+			if err != nil {
+				return "", err
+			}
+			if len(msgs) > 0 && format.ParseCommitMsg(msgs[0]) != nil {
+				proposal = strings.SplitN(msgs[0], "\n", 2)[0]
+				break
+			}
+		}
+	}
+
+	if opt.Interactive {
+		sid, err := c.ShortId()
+		if err != nil {
+			return "", err
+		}
+		// No default, so that an empty answer keeps the header. The proposal is completed with tab.
+		prompt := &survey.Input{Message: fmt.Sprintf("%s '%s' (empty to keep)", sid, summary)}
+		if proposal != "" {
+			prompt.Help = fmt.Sprintf("Proposed header: %s", proposal)
+			prompt.Suggest = func(string) []string {
+				return []string{proposal}
+			}
+		}
+		header := ""
+		err = survey.AskOne(prompt, &header, survey.WithValidator(func(ans interface{}) error {
+			if s, _ := ans.(string); s != "" && format.ParseCommitMsg(s) == nil {
+				return errors.New("not a conventional commit header")
+			}
+			return nil
+		}))
+		return header, err
+	}
+	return proposal, nil
+}
+
+// writeMigrateMapping writes '<old sha> <new sha>' lines in walk order.
+func writeMigrateMapping(path string, order []git.Oid, mapping map[git.Oid]*git.Oid) error {
+	var sb strings.Builder
+	for _, old := range order {
+		fmt.Fprintf(&sb, "%s %s\n", old.String(), mapping[old])
+	}
+	return ioutil.WriteFile(path, []byte(sb.String()), os.FileMode(0644))
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrateRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "turbogit-test-migrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rules.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("- match: '(?i)^fixe?d? (.*)'\n  replace: 'fix: $1'\n"), 0644))
+	rules, err := loadMigrateRules(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "(?i)^fixe?d? (.*)", rules[0].Match.String())
+	assert.Equal(t, "fix: $1", rules[0].Replace)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("- match: '('\n  replace: 'fix: $1'\n"), 0644))
+	_, err = loadMigrateRules(path)
+	assert.Error(t, err)
+	require.NoError(t, ioutil.WriteFile(path, []byte("- match: 'foo'\n"), 0644))
+	_, err = loadMigrateRules(path)
+	assert.Error(t, err)
+}
+
+func TestRunMigrate(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	c1, err := tugit.Commit(r, "feat: initial")
+	require.NoError(t, err)
+	c2, err := tugit.Commit(r, "fixed stuff\n\nwith a body")
+	require.NoError(t, err)
+	c3, err := tugit.Commit(r, "random work")
+	require.NoError(t, err)
+
+	mapping := filepath.Join(r.Path(), "map")
+	opt := &migrateOpt{
+		Source:  "HEAD",
+		Branch:  "migrated",
+		Rules:   []*migrateRule{{Match: regexp.MustCompile(`(?i)^fixe?d? (.*)`), Replace: "fix: $1"}},
+		Mapping: mapping,
+		Repo:    r,
+	}
+	require.NoError(t, runMigrate(opt))
+
+	b, err := r.LookupBranch("migrated", git.BranchLocal)
+	require.NoError(t, err)
+	nc3, err := r.LookupCommit(b.Target())
+	require.NoError(t, err)
+	assert.Equal(t, "random work", nc3.Message())
+	assert.Equal(t, c3.TreeId(), nc3.TreeId())
+	nc2 := nc3.Parent(0)
+	assert.Equal(t, "fix: stuff\n\nwith a body", nc2.Message())
+	assert.Equal(t, c2.Author().When.Unix(), nc2.Author().When.Unix())
+	assert.Equal(t, c1.Id(), nc2.ParentId(0))
+
+	raw, err := ioutil.ReadFile(mapping)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s %s\n%s %s\n%s %s\n", c1.Id(), c1.Id(), c2.Id(), nc2.Id(), c3.Id(), nc3.Id()), string(raw))
+
+	// Source is untouched
+	head, err := r.Head()
+	require.NoError(t, err)
+	assert.Equal(t, c3.Id(), head.Target())
+
+	// Existing branch
+	assert.EqualError(t, runMigrate(opt), "Branch migrated already exists.")
+}
@@ -23,10 +23,12 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/b4nst/turbogit/internal/cmdbuilder"
 	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
 	"github.com/b4nst/turbogit/pkg/integrations"
 	git "github.com/libgit2/git2go/v33"
 	"github.com/spf13/cobra"
@@ -116,7 +118,8 @@ func runNew(opt *newOpt) error {
 	}
 
 	// Create new branch
-	b, err := r.CreateBranch(opt.NewBranch.String(), t, false)
+	name := opt.NewBranch.String()
+	b, err := tugit.CreateBranch(r, name, t.Id(), tugit.ReflogMessage("new", fmt.Sprintf("created from %s", head.Shorthand())))
 	if err != nil {
 		return err
 	}
@@ -134,7 +137,7 @@ func runNew(opt *newOpt) error {
 	if err != nil {
 		return err
 	}
-	err = r.SetHead(b.Reference.Name())
+	_, err = r.References.CreateSymbolic("HEAD", b.Reference.Name(), true, tugit.ReflogMessage("new", fmt.Sprintf("moving from %s to %s", head.Shorthand(), name)))
 	return err
 }
 
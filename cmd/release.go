@@ -26,9 +26,11 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/b4nst/turbogit/internal/cmdbuilder"
 	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
 	"github.com/blang/semver/v4"
 	git "github.com/libgit2/git2go/v33"
 	"github.com/spf13/cobra"
@@ -86,9 +88,9 @@ func runRelease(opt *releaseOpt) error {
 	}
 
 	// find next version
-	bump := format.BUMP_NONE
+	bc := newBumpCollector()
 	curr := semver.Version{}
-	walker, err := commitWalker(&bump, &curr, opt.Prefix)
+	walker, err := commitWalker(bc, &curr, opt.Prefix)
 	if err != nil {
 		return err
 	}
@@ -96,6 +98,7 @@ func runRelease(opt *releaseOpt) error {
 		return err
 	}
 
+	bump := bc.Bump()
 	if bump == format.BUMP_NONE {
 		fmt.Println("Nothing to do")
 		return nil
@@ -106,7 +109,7 @@ func runRelease(opt *releaseOpt) error {
 	}
 
 	// do tag
-	tagname := fmt.Sprintf("refs/tags/%s%s", opt.Prefix, curr)
+	tagname := fmt.Sprintf("%s%s", opt.Prefix, curr)
 	return tagHead(opt.Repo, tagname, opt.DryRun)
 }
 
@@ -118,7 +121,7 @@ func tagHead(r *git.Repository, tagname string, dry bool) error {
 	if dry {
 		fmt.Println(tagname, "would be created on", head.Target())
 	} else {
-		tag, err := r.References.Create(tagname, head.Target(), false, "")
+		tag, err := tugit.CreateTag(r, tagname, head.Target(), tugit.ReflogMessage("release", tagname))
 		if err != nil {
 			return err
 		}
@@ -146,7 +149,46 @@ func bumpVersion(curr *semver.Version, bump format.Bump) error {
 	}
 }
 
-func commitWalker(bump *format.Bump, curr *semver.Version, prefix string) (func(*git.Commit) bool, error) {
+// bumpCollector computes the bump of the commits released together.
+// A revert cancels the reverted commit when both are released together, otherwise it is a patch.
+type bumpCollector struct {
+	bump format.Bump
+	// Reverted commit IDs, true until the reverted commit is collected
+	reverted map[string]bool
+}
+
+func newBumpCollector() *bumpCollector {
+	return &bumpCollector{reverted: map[string]bool{}}
+}
+
+// add collects a commit. Commits must be added newest first, so that reverts come before the reverted commits.
+func (bc *bumpCollector) add(c *git.Commit) {
+	id := c.Id().String()
+	for rid := range bc.reverted {
+		if strings.HasPrefix(id, rid) {
+			// Reverted before being released
+			bc.reverted[rid] = false
+			return
+		}
+	}
+	if rid := format.RevertedCommit(c.Message()); rid != "" {
+		bc.reverted[rid] = true
+		return
+	}
+	bc.bump = format.NextBump(c.Message(), bc.bump)
+}
+
+// Bump returns the bump of the collected commits.
+func (bc *bumpCollector) Bump() format.Bump {
+	for _, pending := range bc.reverted {
+		if pending && bc.bump < format.BUMP_PATCH {
+			return format.BUMP_PATCH
+		}
+	}
+	return bc.bump
+}
+
+func commitWalker(bc *bumpCollector, curr *semver.Version, prefix string) (func(*git.Commit) bool, error) {
 	dfo, err := git.DefaultDescribeFormatOptions()
 	if err != nil {
 		return nil, err
@@ -162,7 +204,7 @@ func commitWalker(bump *format.Bump, curr *semver.Version, prefix string) (func(
 		dr, err := c.Describe(dco)
 		if err != nil {
 			// No next tag matching
-			*bump = format.NextBump(c.Message(), *bump)
+			bc.add(c)
 			return true
 		}
 		d, err := dr.Format(&dfo)
@@ -177,7 +219,7 @@ func commitWalker(bump *format.Bump, curr *semver.Version, prefix string) (func(
 		if offset <= 1 {
 			return false
 		}
-		*bump = format.NextBump(c.Message(), *bump)
+		bc.add(c)
 		return true
 	}, nil
 }
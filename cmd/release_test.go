@@ -5,8 +5,12 @@ import (
 	"testing"
 
 	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
 	"github.com/blang/semver/v4"
+	git "github.com/libgit2/git2go/v33"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseDescription(t *testing.T) {
@@ -77,3 +81,36 @@ func TestBumpVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestBumpCollector(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	breaking, err := tugit.Commit(r, "feat!: break")
+	require.NoError(t, err)
+	revert, err := tugit.Commit(r, format.RevertMessage(breaking.Summary(), breaking.Id().String()))
+	require.NoError(t, err)
+	feat, err := tugit.Commit(r, "feat: feature")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		commits []*git.Commit
+		bump    format.Bump
+	}{
+		{"revert released together", []*git.Commit{revert, breaking}, format.BUMP_NONE},
+		{"revert of released commit", []*git.Commit{revert}, format.BUMP_PATCH},
+		{"revert and feature", []*git.Commit{feat, revert}, format.BUMP_MINOR},
+		{"no revert", []*git.Commit{breaking}, format.BUMP_MAJOR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := newBumpCollector()
+			for _, c := range tt.commits {
+				bc.add(c)
+			}
+			assert.Equal(t, tt.bump, bc.Bump())
+		})
+	}
+}
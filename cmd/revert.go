@@ -0,0 +1,133 @@
+/*
+Copyright © 2022 banst
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/b4nst/turbogit/internal/cmdbuilder"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(RevertCmd)
+
+	cmdbuilder.RepoAware(RevertCmd)
+
+	RevertCmd.Flags().UintP("mainline", "m", 0, "Parent number to revert to when reverting a merge commit")
+	RevertCmd.Flags().BoolP("edit", "e", false, "Prompt editor to edit the revert message")
+}
+
+// RevertCmd represents the revert command
+var RevertCmd = &cobra.Command{
+	Use:   "revert <rev>",
+	Short: "Revert a commit with a conventional message.",
+	Long: `
+Apply the reverse of the changes introduced by a commit and commit them as 'revert: <original header>'.
+The body says which commit is reverted and a Refs footer references it.
+
+On release, a revert and the commit it reverts cancel each other when none of them was released yet, so that reverting
+an unreleased breaking feature does not bump the major version. Reverting an already released commit is a patch.
+
+If the revert conflicts, resolve the conflicts then run 'tug commit' to conclude it.
+`,
+	Example: `
+# Revert the last commit
+$ tug revert HEAD
+
+# Revert a merge commit to its first parent
+$ tug revert -m 1 4f2a1c3
+`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		opt := &revertOpt{Rev: args[0]}
+		var err error
+
+		opt.Mainline, err = cmd.Flags().GetUint("mainline")
+		cobra.CheckErr(err)
+		opt.Edit, err = cmd.Flags().GetBool("edit")
+		cobra.CheckErr(err)
+		opt.Repo = cmdbuilder.GetRepo(cmd)
+
+		cobra.CheckErr(runRevert(opt))
+	},
+}
+
+type revertOpt struct {
+	// Commit to revert
+	Rev string
+	// Parent to revert to for merge commits
+	Mainline uint
+	// Prompt editor
+	Edit bool
+	Repo *git.Repository
+}
+
+func runRevert(opt *revertOpt) error {
+	r := opt.Repo
+	if r.State() != git.RepositoryStateNone {
+		return errors.New("An operation is already in progress, conclude it with 'tug commit' first")
+	}
+	if staged, _ := tugit.StageReady(r); staged {
+		return errors.New("Your staged changes would be committed with the revert, commit or unstage them first")
+	}
+	id, err := tugit.ResolveCommit(r, opt.Rev)
+	if err != nil {
+		return err
+	}
+	target, err := r.LookupCommit(id)
+	if err != nil {
+		return err
+	}
+	defer target.Free()
+	if target.ParentCount() > 1 && opt.Mainline == 0 {
+		return fmt.Errorf("%s is a merge, choose the parent to revert to with --mainline", opt.Rev)
+	}
+
+	if err := tugit.Revert(r, target, opt.Mainline); err != nil {
+		if r.State() == git.RepositoryStateRevert {
+			return fmt.Errorf("%w, then run 'tug commit'", err)
+		}
+		return err
+	}
+	cmsg, err := buildCommitMessage(format.RevertMessage(target.Summary(), target.Id().String()), &commitOpt{Repo: r, PromptEditor: opt.Edit})
+	if err != nil {
+		return fmt.Errorf("%w, run 'tug commit' to conclude the revert", err)
+	}
+	commit, err := tugit.Commit(r, cmsg)
+	if err != nil {
+		return err
+	}
+	defer commit.Free()
+
+	h, err := commit.ShortId()
+	if err != nil {
+		return err
+	}
+	fmt.Println(h, commit.Summary())
+	return nil
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRevert(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	_, err := tugit.Commit(r, "chore: init")
+	require.NoError(t, err)
+	f := test.NewFile(t, r)
+	test.StageFile(t, f, r)
+	target, err := tugit.Commit(r, "feat(api)!: break")
+	require.NoError(t, err)
+
+	require.NoError(t, runRevert(&revertOpt{Rev: "HEAD", Repo: r}))
+	head, err := r.Head()
+	require.NoError(t, err)
+	c, err := r.LookupCommit(head.Target())
+	require.NoError(t, err)
+	sha := target.Id().String()
+	assert.Equal(t, "revert: feat(api)!: break\n\nThis reverts commit "+sha+".\n\nRefs: "+sha, c.Message())
+	assert.Equal(t, git.RepositoryStateNone, r.State())
+	assert.NoFileExists(t, f.Name())
+}
@@ -0,0 +1,213 @@
+/*
+Copyright © 2022 banst
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/b4nst/turbogit/internal/cmdbuilder"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Default protected upstreams
+	DEFAULT_PROTECTED = "origin/HEAD"
+)
+
+func init() {
+	RootCmd.AddCommand(RewordCmd)
+
+	cmdbuilder.RepoAware(RewordCmd)
+
+	RewordCmd.Flags().StringP("type", "t", "", fmt.Sprintf("Commit types %s", format.AllCommitType()))
+	RewordCmd.RegisterFlagCompletionFunc("type", typeFlagCompletion)
+	RewordCmd.Flags().BoolP("breaking-changes", "c", false, "Commit contains breaking changes")
+	RewordCmd.Flags().BoolP("edit", "e", false, "Prompt editor to edit your message (add body or/and footer(s))")
+	RewordCmd.Flags().StringP("scope", "s", "", "Add a scope")
+}
+
+// RewordCmd represents the reword command
+var RewordCmd = &cobra.Command{
+	Use:   "reword <rev> [subject]",
+	Short: "Reword any commit of the current branch.",
+	Long: `
+Reword a commit of the current branch, applying the same overrides as commit, then rebuild its descendants on top of it.
+Commits already on a protected upstream are never rewritten. Protected upstreams are configured as a comma separated
+list of remote branch patterns in tug.protected (default origin/HEAD).
+`,
+	Example: `
+# Change the type of the commit before HEAD
+$ tug reword HEAD~1 -t fix
+
+# Add a scope and mark a commit as breaking
+$ tug reword 4f2a1c3 -s api -c
+
+# Rewrite the description and edit the body
+$ tug reword HEAD~2 -e better description
+`,
+	Args: cobra.MinimumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		opt := &rewordOpt{Rev: args[0], commitOpt: &commitOpt{}}
+		var err error
+
+		// --type
+		fType, err := cmd.Flags().GetString("type")
+		cobra.CheckErr(err)
+		opt.CType = format.FindCommitType(fType)
+		// --breaking-changes
+		opt.BreakingChanges, err = cmd.Flags().GetBool("breaking-changes")
+		cobra.CheckErr(err)
+		// --scope
+		opt.Scope, err = cmd.Flags().GetString("scope")
+		cobra.CheckErr(err)
+		// --edit
+		opt.PromptEditor, err = cmd.Flags().GetBool("edit")
+		cobra.CheckErr(err)
+
+		opt.Message = strings.Join(args[1:], " ")
+		opt.Repo = cmdbuilder.GetRepo(cmd)
+
+		cobra.CheckErr(runReword(opt))
+	},
+}
+
+type rewordOpt struct {
+	*commitOpt
+	// Commit to reword
+	Rev string
+}
+
+func runReword(opt *rewordOpt) error {
+	r := opt.Repo
+	id, err := tugit.ResolveCommit(r, opt.Rev)
+	if err != nil {
+		return err
+	}
+	target, err := r.LookupCommit(id)
+	if err != nil {
+		return err
+	}
+	defer target.Free()
+	if err := checkProtected(r, target); err != nil {
+		return err
+	}
+
+	cmsg, err := buildCommitMessage(target.Message(), opt.commitOpt)
+	if err != nil {
+		return err
+	}
+	// Messages are compared trimmed, as git and the editor may not keep the trailing line break
+	if strings.TrimSpace(cmsg) == strings.TrimSpace(target.Message()) {
+		fmt.Println("Nothing to reword.")
+		return nil
+	}
+	nid, err := tugit.Reword(r, target, cmsg)
+	if err != nil {
+		return err
+	}
+
+	commit, err := r.LookupCommit(nid)
+	if err != nil {
+		return err
+	}
+	defer commit.Free()
+	h, err := commit.ShortId()
+	if err != nil {
+		return err
+	}
+	fmt.Println(h, commit.Summary())
+	return nil
+}
+
+// protectedUpstreams returns the patterns of the protected remote branches, from tug.protected.
+func protectedUpstreams(r *git.Repository) ([]string, error) {
+	c, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Free()
+	raw, _ := c.LookupString("tug.protected")
+	if raw == "" {
+		raw = DEFAULT_PROTECTED
+	}
+	var patterns []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns, nil
+}
+
+// checkProtected returns an error if c is reachable from a protected upstream.
+func checkProtected(r *git.Repository, c *git.Commit) error {
+	patterns, err := protectedUpstreams(r)
+	if err != nil {
+		return err
+	}
+	it, err := r.NewReferenceIteratorGlob("refs/remotes/*")
+	if err != nil {
+		return err
+	}
+	defer it.Free()
+
+	for {
+		ref, err := it.Next()
+		if git.IsErrorCode(err, git.ErrorCodeIterOver) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := ref.Shorthand()
+		protected := false
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				protected = true
+				break
+			}
+		}
+		if !protected {
+			continue
+		}
+		resolved, err := ref.Resolve()
+		if err != nil {
+			return err
+		}
+		tip := resolved.Target()
+		reachable := tip.Equal(c.Id())
+		if !reachable {
+			if reachable, err = r.DescendantOf(tip, c.Id()); err != nil {
+				return err
+			}
+		}
+		if reachable {
+			return fmt.Errorf("%s is already on protected upstream %s, refusing to rewrite it", c.Id(), name)
+		}
+	}
+}
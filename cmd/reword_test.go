@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReword(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	c1, err := tugit.Commit(r, "feat: first")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "feat: second\n\nWith a body")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "feat: third")
+	require.NoError(t, err)
+
+	opt := &rewordOpt{Rev: "HEAD~1", commitOpt: &commitOpt{CType: format.FixCommit, Scope: "api", Repo: r}}
+	require.NoError(t, runReword(opt))
+
+	head, err := r.Head()
+	require.NoError(t, err)
+	nc3, err := r.LookupCommit(head.Target())
+	require.NoError(t, err)
+	assert.Equal(t, "feat: third", nc3.Message())
+	assert.Equal(t, "fix(api): second\n\nWith a body", nc3.Parent(0).Message())
+
+	// Protected upstream
+	_, err = r.References.Create("refs/remotes/origin/main", c1.Id(), false, "")
+	require.NoError(t, err)
+	conf, err := r.Config()
+	require.NoError(t, err)
+	require.NoError(t, conf.SetString("tug.protected", "origin/main, origin/release/*"))
+	opt = &rewordOpt{Rev: c1.Id().String(), commitOpt: &commitOpt{CType: format.FixCommit, Repo: r}}
+	assert.EqualError(t, runReword(opt), fmt.Sprintf("%s is already on protected upstream origin/main, refusing to rewrite it", c1.Id()))
+}
+
+func TestRunRewordKeepsBody(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	body := "\n\nFirst paragraph line one\nline two.\n\nNote: not a footer\nSecond paragraph.\n\nRefs: 12\nSigned-off-by: Alice <alice@ecorp.com>\n"
+	_, err := tugit.Commit(r, "feat: x"+body)
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "feat: y")
+	require.NoError(t, err)
+
+	require.NoError(t, runReword(&rewordOpt{Rev: "HEAD~1", commitOpt: &commitOpt{CType: format.FixCommit, Repo: r}}))
+	head, err := r.Head()
+	require.NoError(t, err)
+	c, err := r.LookupCommit(head.Target())
+	require.NoError(t, err)
+	assert.Equal(t, "fix: x"+body, c.Parent(0).Message())
+}
+
+func TestRunRewordNoop(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	_, err := tugit.Commit(r, "feat: x\n\nA body\non two lines.\n\nRefs: 12\n")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "feat: y")
+	require.NoError(t, err)
+	before, err := r.Head()
+	require.NoError(t, err)
+
+	require.NoError(t, runReword(&rewordOpt{Rev: "HEAD~1", commitOpt: &commitOpt{Repo: r}}))
+	after, err := r.Head()
+	require.NoError(t, err)
+	assert.Equal(t, before.Target(), after.Target())
+}
@@ -0,0 +1,345 @@
+/*
+Copyright © 2022 banst
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/b4nst/turbogit/internal/cmdbuilder"
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Key used for commits without scope
+	NO_SCOPE = "(none)"
+)
+
+func init() {
+	RootCmd.AddCommand(StatsCmd)
+
+	cmdbuilder.RepoAware(StatsCmd)
+
+	StatsCmd.Flags().BoolP("all", "a", false, "Aggregate all the commits in refs/*, along with HEAD")
+	StatsCmd.Flags().StringP("from", "f", "HEAD", "Aggregate only commits reachable from this one. Ignored if revision ranges are given.")
+	StatsCmd.Flags().String("since", "", "Aggregate commits more recent than a specific date")
+	StatsCmd.Flags().String("until", "", "Aggregate commits older than a specific date")
+	StatsCmd.Flags().String("date-field", "committer", "Date used by --since and --until (committer or author)")
+	StatsCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+}
+
+// StatsCmd represents the stats command
+var StatsCmd = &cobra.Command{
+	Use:   "stats [<revision range>...]",
+	Short: "Aggregate statistics on the conventional history.",
+	Example: `
+# Statistics of the whole history of the current branch
+$ tug stats
+
+# Statistics of the last month, as JSON
+$ tug stats --since "1 month ago" -o json
+
+# Statistics of the commits of a release
+$ tug stats v1.0.0..v1.1.0
+`,
+	Args: cobra.ArbitraryArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		opt := &statsOpt{Revisions: args}
+		var err error
+
+		// --all
+		opt.All, err = cmd.Flags().GetBool("all")
+		cobra.CheckErr(err)
+		// --from
+		opt.From, err = cmd.Flags().GetString("from")
+		cobra.CheckErr(err)
+		// --since
+		fSince, err := cmd.Flags().GetString("since")
+		cobra.CheckErr(err)
+		if fSince != "" {
+			date, err := dateparse.ParseAny(fSince)
+			cobra.CheckErr(err)
+			opt.Since = &date
+		}
+		// --until
+		fUntil, err := cmd.Flags().GetString("until")
+		cobra.CheckErr(err)
+		if fUntil != "" {
+			date, err := dateparse.ParseAny(fUntil)
+			cobra.CheckErr(err)
+			opt.Until = &date
+		}
+		// --date-field
+		fDateField, err := cmd.Flags().GetString("date-field")
+		cobra.CheckErr(err)
+		opt.DateField, err = ParseDateField(fDateField)
+		cobra.CheckErr(err)
+		// --output
+		opt.Output, err = cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+		if opt.Output != "table" && opt.Output != "json" {
+			cobra.CheckErr(fmt.Errorf("unknown output '%s', expected table or json", opt.Output))
+		}
+
+		opt.Repo = cmdbuilder.GetRepo(cmd)
+
+		cobra.CheckErr(runStats(opt))
+	},
+}
+
+type statsOpt struct {
+	All       bool
+	From      string
+	Revisions []string
+	Since     *time.Time
+	Until     *time.Time
+	DateField DateField
+	Output    string
+	Repo      *git.Repository
+}
+
+// repoStats aggregates the conventional history.
+type repoStats struct {
+	Commits           int                   `json:"commits"`
+	NonCompliant      int                   `json:"non_compliant"`
+	NonCompliantShare float64               `json:"non_compliant_share"`
+	BreakingChanges   int                   `json:"breaking_changes"`
+	BreakingFrequency float64               `json:"breaking_frequency"`
+	Types             map[string]int        `json:"types"`
+	Scopes            map[string]int        `json:"scopes"`
+	Authors           map[string]int        `json:"authors"`
+	Lines             map[string]*lineStats `json:"lines_by_scope"`
+	Weeks             []*weekStats          `json:"weeks"`
+}
+
+type lineStats struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+type weekStats struct {
+	// ISO week (e.g. 2022-W07)
+	Week            string         `json:"week"`
+	Commits         int            `json:"commits"`
+	NonCompliant    int            `json:"non_compliant"`
+	BreakingChanges int            `json:"breaking_changes"`
+	Types           map[string]int `json:"types"`
+}
+
+func newRepoStats() *repoStats {
+	return &repoStats{
+		Types:   map[string]int{},
+		Scopes:  map[string]int{},
+		Authors: map[string]int{},
+		Lines:   map[string]*lineStats{},
+	}
+}
+
+func runStats(opt *statsOpt) error {
+	walk, err := newRevWalk(opt.Repo, opt.All, opt.From, opt.Revisions, false)
+	if err != nil {
+		return err
+	}
+	defer walk.Free()
+
+	// Walk by date, so that --since can stop the walk early
+	order := "date"
+	if opt.DateField == AuthorDate {
+		order = "author-date"
+	}
+	filters := []LogFilter{
+		Since(opt.Since, opt.DateField, true),
+		Until(opt.Until, opt.DateField),
+	}
+	st, err := collectStats(walk, order, opt.DateField, filters)
+	if err != nil {
+		return err
+	}
+
+	if opt.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	}
+	writeStatsTable(os.Stdout, st)
+	return nil
+}
+
+// collectStats aggregates the commits of the walk passing the filters.
+func collectStats(walk *git.RevWalk, order string, field DateField, filters []LogFilter) (*repoStats, error) {
+	st := newRepoStats()
+	weeks := map[string]*weekStats{}
+	var aerr error
+	err := walkCommits(walk, order, func(c *git.Commit) bool {
+		co := format.ParseCommitMsg(c.Message())
+		keep, walk := ApplyFilters(c, orEmpty(co), filters...)
+		if !keep {
+			return walk
+		}
+		if aerr = st.add(c, co, weeks, field); aerr != nil {
+			return false
+		}
+		return walk
+	})
+	if err != nil {
+		return nil, err
+	}
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	for _, w := range weeks {
+		st.Weeks = append(st.Weeks, w)
+	}
+	sort.Slice(st.Weeks, func(i, j int) bool {
+		return st.Weeks[i].Week < st.Weeks[j].Week
+	})
+	if st.Commits > 0 {
+		st.NonCompliantShare = float64(st.NonCompliant) / float64(st.Commits)
+		st.BreakingFrequency = float64(st.BreakingChanges) / float64(st.Commits)
+	}
+	return st, nil
+}
+
+// add accounts a commit in the statistics.
+func (st *repoStats) add(c *git.Commit, co *format.CommitMessageOption, weeks map[string]*weekStats, field DateField) error {
+	year, wn := field.Of(c).ISOWeek()
+	wk := fmt.Sprintf("%d-W%02d", year, wn)
+	week, ok := weeks[wk]
+	if !ok {
+		week = &weekStats{Week: wk, Types: map[string]int{}}
+		weeks[wk] = week
+	}
+
+	st.Commits++
+	week.Commits++
+	st.Authors[c.Author().Name]++
+	// Unknown types (e.g. 'foo: bar') parse but are not conventional
+	if co == nil || co.Ctype == format.NilCommit {
+		st.NonCompliant++
+		week.NonCompliant++
+		return nil
+	}
+	// Co-authors are credited once per commit
+	credited := map[string]bool{c.Author().Name: true}
+	for _, ident := range co.CoAuthors() {
+		if name, _ := format.ParseIdent(ident); name != "" && !credited[name] {
+			credited[name] = true
+			st.Authors[name]++
+		}
+	}
+
+	ctype := co.Ctype.String()
+	st.Types[ctype]++
+	week.Types[ctype]++
+	if co.BreakingChanges {
+		st.BreakingChanges++
+		week.BreakingChanges++
+	}
+	scope := co.Scope
+	if scope == "" {
+		scope = NO_SCOPE
+	}
+	st.Scopes[scope]++
+
+	// Merge commits would account the whole merged branch again
+	if c.ParentCount() > 1 {
+		return nil
+	}
+	diff, err := tugit.CommitDiff(c)
+	if err != nil {
+		return err
+	}
+	defer diff.Free()
+	ds, err := diff.Stats()
+	if err != nil {
+		return err
+	}
+	defer ds.Free()
+	ls, ok := st.Lines[scope]
+	if !ok {
+		ls = &lineStats{}
+		st.Lines[scope] = ls
+	}
+	ls.Added += ds.Insertions()
+	ls.Removed += ds.Deletions()
+	return nil
+}
+
+// sortedKeys returns the keys of m, by decreasing value then by name.
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func writeStatsTable(w io.Writer, st *repoStats) {
+	tw := tabwriter.NewWriter(w, 10, 1, 2, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprintf(tw, "Commits:\t%d\t\n", st.Commits)
+	fmt.Fprintf(tw, "Non compliant:\t%d\t(%.1f%%)\n", st.NonCompliant, st.NonCompliantShare*100)
+	fmt.Fprintf(tw, "Breaking changes:\t%d\t(%.1f%%)\n", st.BreakingChanges, st.BreakingFrequency*100)
+
+	fmt.Fprintln(tw, "\nTYPE\tCOMMITS\t")
+	for _, k := range sortedKeys(st.Types) {
+		fmt.Fprintf(tw, "%s\t%d\t\n", k, st.Types[k])
+	}
+
+	fmt.Fprintln(tw, "\nSCOPE\tCOMMITS\tADDED\tREMOVED\t")
+	for _, k := range sortedKeys(st.Scopes) {
+		ls, ok := st.Lines[k]
+		if !ok {
+			ls = &lineStats{}
+		}
+		fmt.Fprintf(tw, "%s\t%d\t+%d\t-%d\t\n", k, st.Scopes[k], ls.Added, ls.Removed)
+	}
+
+	fmt.Fprintln(tw, "\nAUTHOR\tCOMMITS\t")
+	for _, k := range sortedKeys(st.Authors) {
+		fmt.Fprintf(tw, "%s\t%d\t\n", k, st.Authors[k])
+	}
+
+	fmt.Fprintln(tw, "\nWEEK\tCOMMITS\tFEAT\tFIX\tBREAKING\tNON COMPLIANT\t")
+	for _, wk := range st.Weeks {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t\n", wk.Week, wk.Commits,
+			wk.Types[format.FeatureCommit.String()], wk.Types[format.FixCommit.String()], wk.BreakingChanges, wk.NonCompliant)
+	}
+}
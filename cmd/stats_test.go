@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectStats(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	f := test.NewFile(t, r)
+	fmt.Fprintln(f, "foo\nbar")
+	test.StageFile(t, f, r)
+	_, err := tugit.Commit(r, "feat(api): add endpoint")
+	require.NoError(t, err)
+	f = test.NewFile(t, r)
+	fmt.Fprintln(f, "baz")
+	test.StageFile(t, f, r)
+	_, err = tugit.Commit(r, "fix: a bug\n\nCo-authored-by: Bob <bob@example.com>")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "bad commit")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "foo: bar")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "feat(api)!: break the api\n\nCo-authored-by: Alice <alice@ecorp.com>")
+	require.NoError(t, err)
+
+	walk, err := newRevWalk(r, false, "HEAD", nil, false)
+	require.NoError(t, err)
+	defer walk.Free()
+	st, err := collectStats(walk, "date", CommitterDate, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, st.Commits)
+	assert.Equal(t, 2, st.NonCompliant)
+	assert.Equal(t, 0.4, st.NonCompliantShare)
+	assert.Equal(t, 1, st.BreakingChanges)
+	assert.Equal(t, map[string]int{"feat": 2, "fix": 1}, st.Types)
+	assert.Equal(t, map[string]int{"api": 2, NO_SCOPE: 1}, st.Scopes)
+	assert.Equal(t, map[string]int{test.GIT_USERNAME: 5, "Bob": 1}, st.Authors)
+	assert.Equal(t, map[string]*lineStats{"api": {Added: 2}, NO_SCOPE: {Added: 1}}, st.Lines)
+	require.Len(t, st.Weeks, 1)
+	assert.Equal(t, 5, st.Weeks[0].Commits)
+	assert.Equal(t, 2, st.Weeks[0].NonCompliant)
+}
+
+func TestSortedKeys(t *testing.T) {
+	assert.Equal(t, []string{"b", "a", "c"}, sortedKeys(map[string]int{"a": 1, "b": 2, "c": 1}))
+}
@@ -0,0 +1,274 @@
+/*
+Copyright © 2022 banst
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/b4nst/turbogit/internal/cmdbuilder"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Reflog details of branch switches and renames
+	movingRe  = regexp.MustCompile(`moving from (\S+) to (\S+)$`)
+	renamedRe = regexp.MustCompile(`renamed (\S+) to (\S+)$`)
+)
+
+func init() {
+	RootCmd.AddCommand(UndoCmd)
+
+	cmdbuilder.RepoAware(UndoCmd)
+
+	UndoCmd.Flags().BoolP("list", "l", false, "List the recent reference updates made by tug")
+	UndoCmd.Flags().IntP("limit", "n", 10, "Number of updates to list")
+	UndoCmd.Flags().Bool("reset-index", false, "Also reset the index to the restored commit (default keeps the index)")
+	UndoCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+}
+
+// UndoCmd represents the undo command
+var UndoCmd = &cobra.Command{
+	Use:   "undo [<n>]",
+	Short: "Undo a branch or tag update made by tug.",
+	Long: `
+Every branch or tag update made by tug (commit, amend, new, reword, migrate, release...) is recorded in the reflog
+with a 'tug: <operation>: <detail>' message. Undo restores the reference as it was before the n-th most recent of
+these updates (1 by default), if it did not move since.
+
+Created branches and tags are deleted, switching back to the previous branch if needed. Renamed branches get their
+name back. The index and the working tree are kept, unless --reset-index is set, and undoing an undo redoes it.
+`,
+	Example: `
+# List the recent tug updates
+$ tug undo -l
+
+# Undo the last amend, keeping the amended changes staged
+$ tug undo
+
+# Undo the third most recent update and unstage its changes
+$ tug undo 3 --reset-index
+`,
+	Args: cobra.MaximumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		opt := &undoOpt{Index: 1}
+		var err error
+
+		if len(args) > 0 {
+			opt.Index, err = strconv.Atoi(args[0])
+			cobra.CheckErr(err)
+		}
+		opt.List, err = cmd.Flags().GetBool("list")
+		cobra.CheckErr(err)
+		opt.Limit, err = cmd.Flags().GetInt("limit")
+		cobra.CheckErr(err)
+		opt.ResetIndex, err = cmd.Flags().GetBool("reset-index")
+		cobra.CheckErr(err)
+		opt.Yes, err = cmd.Flags().GetBool("yes")
+		cobra.CheckErr(err)
+		opt.Repo = cmdbuilder.GetRepo(cmd)
+
+		cobra.CheckErr(runUndo(opt))
+	},
+}
+
+type undoOpt struct {
+	// 1-based index of the update to undo, most recent first
+	Index      int
+	List       bool
+	Limit      int
+	ResetIndex bool
+	Yes        bool
+	Repo       *git.Repository
+}
+
+func runUndo(opt *undoOpt) error {
+	r := opt.Repo
+	if opt.List {
+		entries, err := tugit.TugReflog(r, opt.Limit)
+		if err != nil {
+			return err
+		}
+		for i, e := range entries {
+			fmt.Printf("%2d  %s  %s\n", i+1, e.Committer.When.Format("2006-01-02 15:04:05"), describeUpdate(e))
+		}
+		return nil
+	}
+
+	if opt.Index < 1 {
+		return fmt.Errorf("invalid update number %d", opt.Index)
+	}
+	entries, err := tugit.TugReflog(r, opt.Index)
+	if err != nil {
+		return err
+	}
+	if len(entries) < opt.Index {
+		return errors.New("No tug update to undo")
+	}
+	e := entries[opt.Index-1]
+
+	if !opt.Yes {
+		confirm := false
+		prompt := &survey.Confirm{Message: fmt.Sprintf("Undo %s?", describeUpdate(e))}
+		if err := survey.AskOne(prompt, &confirm); err != nil {
+			return err
+		}
+		if !confirm {
+			return nil
+		}
+	}
+	return undoUpdate(r, e, opt.ResetIndex)
+}
+
+// describeUpdate returns a one line description of an update.
+func describeUpdate(e *tugit.ReflogEntry) string {
+	change := "created " + e.New.String()[:7]
+	if !e.IsCreation() {
+		change = e.Old.String()[:7] + ".." + e.New.String()[:7]
+	}
+	return fmt.Sprintf("%s %s (%s)", shortRef(e.Ref), change, strings.TrimPrefix(e.Message, tugit.REFLOG_PREFIX))
+}
+
+// shortRef returns the branch or tag name of a reference.
+func shortRef(name string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(name, "refs/heads/"), "refs/tags/")
+}
+
+// undoUpdate restores the reference as it was before the update, if it did not move since.
+func undoUpdate(r *git.Repository, e *tugit.ReflogEntry, resetIndex bool) error {
+	ref, err := r.References.Lookup(e.Ref)
+	if err != nil {
+		return fmt.Errorf("%s no longer exists", shortRef(e.Ref))
+	}
+	defer ref.Free()
+	if !ref.Target().Equal(e.New) {
+		return fmt.Errorf("%s moved since, refusing to undo", shortRef(e.Ref))
+	}
+	msg := tugit.ReflogMessage("undo", strings.TrimPrefix(e.Message, tugit.REFLOG_PREFIX))
+
+	current := false
+	if head, err := r.Head(); err == nil {
+		current = head.Name() == e.Ref
+		head.Free()
+	}
+
+	switch {
+	case renamedRe.MatchString(e.Message) && e.Old.Equal(e.New):
+		// The other name, undone renames are logged in the same order
+		m := renamedRe.FindStringSubmatch(e.Message)
+		old := m[1]
+		if old == shortRef(e.Ref) {
+			old = m[2]
+		}
+		renamed, err := tugit.RenameBranch(r, ref, old, msg)
+		if err != nil {
+			return err
+		}
+		renamed.Free()
+		fmt.Printf("Renamed %s back to %s.\n", shortRef(e.Ref), old)
+	case e.IsCreation():
+		if current {
+			if err := switchBack(r, e, msg); err != nil {
+				return err
+			}
+		}
+		if err := ref.Delete(); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %s (was %s).\n", shortRef(e.Ref), e.New.String()[:7])
+	default:
+		moved, err := ref.SetTarget(e.Old, msg)
+		if err != nil {
+			return err
+		}
+		moved.Free()
+		if current && resetIndex {
+			if err := resetIndexTo(r, e.Old); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("%s restored to %s.\n", shortRef(e.Ref), e.Old.String()[:7])
+	}
+	return nil
+}
+
+// switchBack checks out the branch HEAD was on before switching to the created branch of e.
+func switchBack(r *git.Repository, e *tugit.ReflogEntry, msg string) error {
+	log, err := tugit.ReadReflog(r, "HEAD")
+	if err != nil {
+		return err
+	}
+	name := shortRef(e.Ref)
+	for _, he := range log {
+		m := movingRe.FindStringSubmatch(he.Message)
+		if m == nil || m[2] != name {
+			continue
+		}
+		prev, err := r.LookupBranch(m[1], git.BranchLocal)
+		if err != nil {
+			return fmt.Errorf("%s is checked out and the previous branch %s no longer exists", name, m[1])
+		}
+		defer prev.Free()
+		c, err := r.LookupCommit(prev.Target())
+		if err != nil {
+			return err
+		}
+		defer c.Free()
+		tree, err := c.Tree()
+		if err != nil {
+			return err
+		}
+		defer tree.Free()
+		if err := r.CheckoutTree(tree, &git.CheckoutOpts{Strategy: git.CheckoutSafe}); err != nil {
+			return err
+		}
+		head, err := r.References.CreateSymbolic("HEAD", prev.Reference.Name(), true, msg)
+		if err != nil {
+			return err
+		}
+		head.Free()
+		return nil
+	}
+	return fmt.Errorf("%s is checked out, switch to another branch first", name)
+}
+
+// resetIndexTo replaces the index by the tree of a commit.
+func resetIndexTo(r *git.Repository, id *git.Oid) error {
+	c, err := r.LookupCommit(id)
+	if err != nil {
+		return err
+	}
+	defer c.Free()
+	tree, err := c.Tree()
+	if err != nil {
+		return err
+	}
+	defer tree.Free()
+	return tugit.ResetIndex(r, tree)
+}
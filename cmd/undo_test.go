@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUndo(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	_, err := tugit.Commit(r, "feat: first")
+	require.NoError(t, err)
+	initial, err := r.Head()
+	require.NoError(t, err)
+	second, err := tugit.Commit(r, "fix: second")
+	require.NoError(t, err)
+	amended, err := tugit.Amend(second, "fix: amended")
+	require.NoError(t, err)
+	headTarget := func() *git.Oid {
+		head, err := r.Head()
+		require.NoError(t, err)
+		return head.Target()
+	}
+
+	// Undo the amend, then redo it
+	require.NoError(t, runUndo(&undoOpt{Index: 1, Yes: true, Repo: r}))
+	assert.Equal(t, second.Id(), headTarget())
+	require.NoError(t, runUndo(&undoOpt{Index: 1, Yes: true, Repo: r}))
+	assert.Equal(t, amended.Id(), headTarget())
+
+	// Refuse to undo an update overwritten since
+	assert.EqualError(t, runUndo(&undoOpt{Index: 4, Yes: true, Repo: r}), initial.Shorthand()+" moved since, refusing to undo")
+
+	// Undo a new branch, switching back
+	require.NoError(t, runNew(&newOpt{NewBranch: format.TugBranch{Type: "feat", Description: "foo"}, Repo: r}))
+	head, err := r.Head()
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/feat/foo", head.Name())
+	log, err := tugit.ReadReflog(r, "refs/heads/feat/foo")
+	require.NoError(t, err)
+	require.NoError(t, undoUpdate(r, log[0], false))
+	head, err = r.Head()
+	require.NoError(t, err)
+	assert.Equal(t, initial.Name(), head.Name())
+	_, err = r.LookupBranch("feat/foo", git.BranchLocal)
+	assert.Error(t, err)
+
+	// Undo a release
+	_, err = tugit.CreateTag(r, "v1.0.0", amended.Id(), tugit.ReflogMessage("release", "v1.0.0"))
+	require.NoError(t, err)
+	log, err = tugit.ReadReflog(r, "refs/tags/v1.0.0")
+	require.NoError(t, err)
+	require.NoError(t, undoUpdate(r, log[0], false))
+	_, err = r.References.Lookup("refs/tags/v1.0.0")
+	assert.Error(t, err)
+}
@@ -28,9 +28,17 @@ func MockRepoAware(cmd *cobra.Command, repo *git.Repository) {
 	cmd.SetContext(context.WithValue(parent, repoKey{}, repo))
 }
 
-func repoPreRun(cmd *cobra.Command, args []string) {
+// OpenRepo opens the repository of the working directory and stores it in the command context.
+func OpenRepo(cmd *cobra.Command) error {
 	repo, err := tugit.Getrepo()
-	cobra.CheckErr(err)
+	if err != nil {
+		return err
+	}
 
 	cmd.SetContext(context.WithValue(cmd.Context(), repoKey{}, repo))
+	return nil
+}
+
+func repoPreRun(cmd *cobra.Command, args []string) {
+	cobra.CheckErr(OpenRepo(cmd))
 }
@@ -0,0 +1,112 @@
+// Package ci detects the commits to check from the CI environment.
+package ci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Default branch reference of the origin remote, used when no CI is detected
+	ORIGIN_HEAD = "refs/remotes/origin/HEAD"
+)
+
+// Range is a revision range detected from the environment.
+type Range struct {
+	// Revision range (e.g. 'base..HEAD')
+	Spec string
+	// Human readable origin of the range
+	Source string
+}
+
+// githubEvent holds the fields of a GitHub event payload used to detect a range.
+type githubEvent struct {
+	Before      string `json:"before"`
+	After       string `json:"after"`
+	PullRequest *struct {
+		Base struct {
+			Sha string `json:"sha"`
+		} `json:"base"`
+		Head struct {
+			Sha string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+}
+
+// DetectRange returns the range of commits introduced by the current merge request or push.
+// It looks in order for GitLab merge request variables, GitHub pull request or push event,
+// and finally falls back to the merge base between HEAD and origin/HEAD.
+func DetectRange(r *git.Repository, getenv func(string) string) (*Range, error) {
+	// GitLab merge request pipelines
+	if base := getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA"); base != "" {
+		return &Range{Spec: base + "..HEAD", Source: "GitLab merge request"}, nil
+	}
+
+	// GitHub actions
+	if getenv("GITHUB_ACTIONS") == "true" {
+		if rg, err := githubRange(getenv); err != nil || rg != nil {
+			return rg, err
+		}
+	}
+
+	// Plain repository
+	return originRange(r)
+}
+
+func githubRange(getenv func(string) string) (*Range, error) {
+	if path := getenv("GITHUB_EVENT_PATH"); path != "" {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		event := &githubEvent{}
+		if err := json.Unmarshal(raw, event); err != nil {
+			return nil, fmt.Errorf("could not parse GitHub event payload: %w", err)
+		}
+		if event.PullRequest != nil && event.PullRequest.Base.Sha != "" {
+			// HEAD is the synthetic merge commit of refs/pull/N/merge by default, the head sha is the tip of the branch
+			head := event.PullRequest.Head.Sha
+			if head == "" {
+				head = "HEAD"
+			}
+			return &Range{Spec: event.PullRequest.Base.Sha + ".." + head, Source: "GitHub pull request"}, nil
+		}
+		// A zero 'before' means the branch was just created
+		if event.Before != "" && strings.Trim(event.Before, "0") != "" {
+			after := event.After
+			if after == "" {
+				after = "HEAD"
+			}
+			return &Range{Spec: event.Before + ".." + after, Source: "GitHub push"}, nil
+		}
+	}
+	if base := getenv("GITHUB_BASE_REF"); base != "" {
+		return &Range{Spec: "origin/" + base + "..HEAD", Source: "GitHub pull request"}, nil
+	}
+	return nil, nil
+}
+
+func originRange(r *git.Repository) (*Range, error) {
+	ref, err := r.References.Lookup(ORIGIN_HEAD)
+	if err != nil {
+		return nil, errors.New("Could not detect the range to check: no CI merge request found and origin/HEAD is not set (run 'git remote set-head origin --auto')")
+	}
+	ref, err = ref.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	base, err := r.MergeBase(ref.Target(), head.Target())
+	if err != nil {
+		return nil, fmt.Errorf("HEAD and %s have no common ancestor: %w", ref.Shorthand(), err)
+	}
+	return &Range{Spec: base.String() + "..HEAD", Source: "merge base with " + ref.Shorthand()}, nil
+}
@@ -0,0 +1,92 @@
+package ci
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(k string) string {
+		return vars[k]
+	}
+}
+
+func eventFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "github-event")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func TestDetectRange(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	base, err := tugit.Commit(r, "feat: base")
+	require.NoError(t, err)
+	_, err = tugit.Commit(r, "feat: new")
+	require.NoError(t, err)
+
+	prEvent := eventFile(t, `{"pull_request": {"base": {"sha": "abc"}}}`)
+	defer os.Remove(prEvent)
+	mergeRefEvent := eventFile(t, `{"pull_request": {"base": {"sha": "abc"}, "head": {"sha": "def"}}}`)
+	defer os.Remove(mergeRefEvent)
+	pushEvent := eventFile(t, `{"before": "abc", "after": "def"}`)
+	defer os.Remove(pushEvent)
+	newBranchEvent := eventFile(t, `{"before": "0000000000000000000000000000000000000000", "after": "def"}`)
+	defer os.Remove(newBranchEvent)
+
+	tcs := map[string]struct {
+		env      map[string]string
+		expected *Range
+	}{
+		"GitLab": {
+			map[string]string{"CI_MERGE_REQUEST_DIFF_BASE_SHA": "abc"},
+			&Range{Spec: "abc..HEAD", Source: "GitLab merge request"},
+		},
+		"GitHub pull request event": {
+			map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_EVENT_PATH": prEvent, "GITHUB_BASE_REF": "main"},
+			&Range{Spec: "abc..HEAD", Source: "GitHub pull request"},
+		},
+		"GitHub pull request merge ref": {
+			map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_EVENT_PATH": mergeRefEvent, "GITHUB_REF": "refs/pull/12/merge"},
+			&Range{Spec: "abc..def", Source: "GitHub pull request"},
+		},
+		"GitHub push event": {
+			map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_EVENT_PATH": pushEvent},
+			&Range{Spec: "abc..def", Source: "GitHub push"},
+		},
+		"GitHub base ref": {
+			map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_EVENT_PATH": newBranchEvent, "GITHUB_BASE_REF": "main"},
+			&Range{Spec: "origin/main..HEAD", Source: "GitHub pull request"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			rg, err := DetectRange(r, env(tc.env))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, rg)
+		})
+	}
+
+	// No origin/HEAD
+	_, err = DetectRange(r, env(nil))
+	assert.Error(t, err)
+
+	// origin/HEAD fallback
+	_, err = r.References.Create(ORIGIN_HEAD, base.Id(), false, "")
+	require.NoError(t, err)
+	rg, err := DetectRange(r, env(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, &Range{Spec: base.Id().String() + "..HEAD", Source: "merge base with origin/HEAD"}, rg)
+}
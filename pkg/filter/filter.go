@@ -0,0 +1,176 @@
+// Package filter implements a small expression language used to select commits.
+//
+// An expression combines predicates on commit fields with '&&', '||', '!' (or 'and', 'or', 'not') and parentheses:
+//
+//	(type in (feat, fix)) && scope == api && !(author =~ "bot") && footer.Refs
+//
+// Supported fields are type, scope, breaking, author, date, files and footer.<key>.
+// A bare field is true when it is set (or true for breaking).
+// List fields (author, files, footer.<key>) match when any of their values matches.
+package filter
+
+import (
+	"regexp"
+	"time"
+)
+
+// Subject is the commit representation an Expression is evaluated against.
+type Subject interface {
+	// Conventional commit type, empty if the commit is not compliant
+	Type() string
+	// Commit scope
+	Scope() string
+	// True if the commit introduces breaking changes
+	Breaking() bool
+	// Author names and emails
+	Authors() []string
+	// Commit date
+	Date() time.Time
+	// Values of the footers with the given key
+	Footers(key string) []string
+	// Paths of the files touched by the commit
+	Files() []string
+}
+
+// Expression is a compiled filter expression.
+type Expression struct {
+	src  string
+	root node
+}
+
+// Compile parses an expression. The returned error is a *ParseError pointing at the faulty position.
+func Compile(expr string) (*Expression, error) {
+	root, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{src: expr, root: root}, nil
+}
+
+// Match returns true if the subject satisfies the expression.
+func (e *Expression) Match(s Subject) bool {
+	return e.root.eval(s)
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.src
+}
+
+type node interface {
+	eval(s Subject) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(s Subject) bool { return n.left.eval(s) && n.right.eval(s) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(s Subject) bool { return n.left.eval(s) || n.right.eval(s) }
+
+type notNode struct{ n node }
+
+func (n notNode) eval(s Subject) bool { return !n.n.eval(s) }
+
+// values returns the string values of a string or list field.
+func values(f field, s Subject) []string {
+	switch f.name {
+	case "type":
+		return []string{s.Type()}
+	case "scope":
+		return []string{s.Scope()}
+	case "author":
+		return s.Authors()
+	case "files":
+		return s.Files()
+	case "footer":
+		return s.Footers(f.key)
+	}
+	return nil
+}
+
+// present is true when a boolean field is true or when a string field is not empty.
+type present struct{ field field }
+
+func (n present) eval(s Subject) bool {
+	switch n.field.kind {
+	case kindBool:
+		return s.Breaking()
+	case kindDate:
+		return !s.Date().IsZero()
+	}
+	for _, v := range values(n.field, s) {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// equal is true when any field value equals any of the expected values.
+type equal struct {
+	field  field
+	values []string
+}
+
+func (n equal) eval(s Subject) bool {
+	for _, v := range values(n.field, s) {
+		for _, e := range n.values {
+			if v == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// match is true when any field value matches the regular expression.
+type match struct {
+	field field
+	re    *regexp.Regexp
+}
+
+func (n match) eval(s Subject) bool {
+	for _, v := range values(n.field, s) {
+		if n.re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameDay is true when the date field is on the same calendar day as date.
+type sameDay struct {
+	field field
+	date  time.Time
+}
+
+func (n sameDay) eval(s Subject) bool {
+	d := s.Date().In(n.date.Location())
+	y1, m1, d1 := d.Date()
+	y2, m2, d2 := n.date.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+// dateCmp compares the date field with date.
+type dateCmp struct {
+	field field
+	op    tokenKind
+	date  time.Time
+}
+
+func (n dateCmp) eval(s Subject) bool {
+	d := s.Date()
+	switch n.op {
+	case tokLt:
+		return d.Before(n.date)
+	case tokLte:
+		return !d.After(n.date)
+	case tokGt:
+		return d.After(n.date)
+	case tokGte:
+		return !d.Before(n.date)
+	}
+	return false
+}
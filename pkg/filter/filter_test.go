@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSubject struct {
+	ctype    string
+	scope    string
+	breaking bool
+	authors  []string
+	date     time.Time
+	footers  map[string][]string
+	files    []string
+}
+
+func (f fakeSubject) Type() string      { return f.ctype }
+func (f fakeSubject) Scope() string     { return f.scope }
+func (f fakeSubject) Breaking() bool    { return f.breaking }
+func (f fakeSubject) Authors() []string { return f.authors }
+func (f fakeSubject) Date() time.Time   { return f.date }
+func (f fakeSubject) Files() []string   { return f.files }
+func (f fakeSubject) Footers(key string) []string {
+	return f.footers[strings.ToLower(key)]
+}
+
+func TestMatch(t *testing.T) {
+	subject := fakeSubject{
+		ctype:   "feat",
+		scope:   "api",
+		authors: []string{"Alice", "alice@ecorp.com"},
+		date:    time.Date(2022, 3, 14, 10, 0, 0, 0, time.UTC),
+		footers: map[string][]string{"refs": {"PROJ-12"}},
+		files:   []string{"services/api/main.go", "README.md"},
+	}
+
+	tcs := map[string]struct {
+		expr     string
+		expected bool
+	}{
+		"Type":               {"type == feat", true},
+		"Type alias":         {"type == feature", true},
+		"Type in":            {"type in (fix, feat)", true},
+		"Type not in":        {"type not in (fix, feat)", false},
+		"Scope":              {"scope != api", false},
+		"Breaking":           {"breaking", false},
+		"Not breaking":       {"breaking == false", true},
+		"Author":             {`author == "alice@ecorp.com"`, true},
+		"Author regexp":      {"!(author =~ bot)", true},
+		"Date before":        {"date < 2022-03-15", true},
+		"Date after":         {"date >= 2022-03-15", false},
+		"Same day":           {"date == 2022-03-14", true},
+		"Footer present":     {"footer.Refs", true},
+		"Footer missing":     {"footer.Closes", false},
+		"Footer value":       {"footer.refs == PROJ-12", true},
+		"Files":              {`files =~ "^services/api/"`, true},
+		"Files not matching": {`files !~ "\.md$"`, false},
+		"Precedence":         {"type == fix || scope == api && !breaking", true},
+		"Parentheses":        {"(type == fix || scope == api) && breaking", false},
+		"Keywords":           {"(type == feat or type == fix) and scope in (api) and not author =~ bot and footer.refs", true},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := Compile(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, e.Match(subject))
+		})
+	}
+}
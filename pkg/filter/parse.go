@@ -0,0 +1,321 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/araddon/dateparse"
+	"github.com/b4nst/turbogit/pkg/format"
+)
+
+// ParseError is returned when an expression cannot be compiled.
+type ParseError struct {
+	// The expression being parsed
+	Expr string
+	// Byte offset of the error in the expression
+	Pos int
+	// Error description
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d\n\t%s\n\t%s^", e.Msg, e.Pos+1, e.Expr, strings.Repeat(" ", e.Pos))
+}
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindList
+	kindBool
+	kindDate
+)
+
+func (k fieldKind) String() string {
+	return [...]string{"string", "list", "boolean", "date"}[k]
+}
+
+// field describes a subject field that can be used in an expression.
+type field struct {
+	name string
+	kind fieldKind
+	// Footer key, only set for footer.<key> fields
+	key string
+}
+
+func lookupField(name string) (field, bool) {
+	switch strings.ToLower(name) {
+	case "type":
+		return field{name: "type", kind: kindString}, true
+	case "scope":
+		return field{name: "scope", kind: kindString}, true
+	case "breaking":
+		return field{name: "breaking", kind: kindBool}, true
+	case "author":
+		return field{name: "author", kind: kindList}, true
+	case "date":
+		return field{name: "date", kind: kindDate}, true
+	case "files":
+		return field{name: "files", kind: kindList}, true
+	}
+	if strings.HasPrefix(strings.ToLower(name), "footer.") && len(name) > len("footer.") {
+		return field{name: "footer", kind: kindList, key: name[len("footer."):]}, true
+	}
+	return field{}, false
+}
+
+type parser struct {
+	expr   string
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, msg string, args ...interface{}) error {
+	return &ParseError{Expr: p.expr, Pos: t.pos, Msg: fmt.Sprintf(msg, args...)}
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t, "expected %s, found %s", kind, describe(t))
+	}
+	return t, nil
+}
+
+// describe returns a human readable representation of a token.
+func describe(t token) string {
+	switch t.kind {
+	case tokIdent:
+		return fmt.Sprintf("'%s'", t.value)
+	case tokString:
+		return fmt.Sprintf("\"%s\"", t.value)
+	default:
+		return t.kind.String()
+	}
+}
+
+// parseOr parses: and ( '||' and )*
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary ( '&&' unary )*
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses: '!' unary | '(' or ')' | predicate
+func (p *parser) parseUnary() (node, error) {
+	switch t := p.peek(); t.kind {
+	case tokNot:
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokLParen:
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokIdent:
+		return p.parsePredicate()
+	default:
+		return nil, p.errorf(t, "expected a field, '!' or '(', found %s", describe(t))
+	}
+}
+
+// parsePredicate parses: field [ op value | ['not'] 'in' '(' value (',' value)* ')' ]
+func (p *parser) parsePredicate() (node, error) {
+	ft := p.next()
+	f, ok := lookupField(ft.value)
+	if !ok {
+		return nil, p.errorf(ft, "unknown field '%s'", ft.value)
+	}
+
+	op := p.peek()
+	switch op.kind {
+	case tokEq, tokNeq, tokMatch, tokNotMatch, tokLt, tokLte, tokGt, tokGte:
+		p.next()
+		vt := p.next()
+		if vt.kind != tokIdent && vt.kind != tokString {
+			return nil, p.errorf(vt, "expected a value, found %s", describe(vt))
+		}
+		return p.compare(f, op, vt)
+	case tokNot:
+		// Only 'not in' is valid after a field
+		p.next()
+		in := p.peek()
+		if in.kind != tokIn {
+			return nil, p.errorf(in, "expected 'in' after 'not', found %s", describe(in))
+		}
+		n, err := p.parseIn(f)
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokIn:
+		return p.parseIn(f)
+	default:
+		// Bare field: boolean test or presence test
+		return present{f}, nil
+	}
+}
+
+func (p *parser) parseIn(f field) (node, error) {
+	in := p.next()
+	if f.kind != kindString && f.kind != kindList {
+		return nil, p.errorf(in, "operator 'in' is not supported on %s field '%s'", f.kind, f.name)
+	}
+	if _, err := p.expect(tokLParen); err != nil {
+		return nil, err
+	}
+	n := equal{field: f}
+	for {
+		vt := p.next()
+		if vt.kind != tokIdent && vt.kind != tokString {
+			return nil, p.errorf(vt, "expected a value, found %s", describe(vt))
+		}
+		v, err := p.stringValue(f, vt)
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, v)
+
+		sep := p.next()
+		if sep.kind == tokRParen {
+			return n, nil
+		}
+		if sep.kind != tokComma {
+			return nil, p.errorf(sep, "expected ',' or ')', found %s", describe(sep))
+		}
+	}
+}
+
+func (p *parser) compare(f field, op token, vt token) (node, error) {
+	switch f.kind {
+	case kindString, kindList:
+		switch op.kind {
+		case tokEq, tokNeq:
+			v, err := p.stringValue(f, vt)
+			if err != nil {
+				return nil, err
+			}
+			return negateIf(op.kind == tokNeq, equal{field: f, values: []string{v}}), nil
+		case tokMatch, tokNotMatch:
+			re, err := regexp.Compile(vt.value)
+			if err != nil {
+				return nil, p.errorf(vt, "invalid regular expression: %s", err)
+			}
+			return negateIf(op.kind == tokNotMatch, match{field: f, re: re}), nil
+		}
+	case kindBool:
+		switch op.kind {
+		case tokEq, tokNeq:
+			var b bool
+			switch strings.ToLower(vt.value) {
+			case "true":
+				b = true
+			case "false":
+				b = false
+			default:
+				return nil, p.errorf(vt, "expected true or false, found %s", describe(vt))
+			}
+			return negateIf((op.kind == tokNeq) == b, present{f}), nil
+		}
+	case kindDate:
+		d, err := dateparse.ParseAny(vt.value)
+		if err != nil {
+			return nil, p.errorf(vt, "invalid date %s", describe(vt))
+		}
+		switch op.kind {
+		case tokEq, tokNeq:
+			return negateIf(op.kind == tokNeq, sameDay{field: f, date: d}), nil
+		case tokLt, tokLte, tokGt, tokGte:
+			return dateCmp{field: f, op: op.kind, date: d}, nil
+		}
+	}
+	return nil, p.errorf(op, "operator %s is not supported on %s field '%s'", op.kind, f.kind, f.name)
+}
+
+// stringValue validates and normalizes a literal compared to a string field.
+func (p *parser) stringValue(f field, vt token) (string, error) {
+	if f.name != "type" || vt.value == "" {
+		return vt.value, nil
+	}
+	ct := format.FindCommitType(vt.value)
+	if ct == format.NilCommit {
+		return "", p.errorf(vt, "unknown commit type %s", describe(vt))
+	}
+	return ct.String(), nil
+}
+
+func negateIf(neg bool, n node) node {
+	if neg {
+		return notNode{n}
+	}
+	return n
+}
+
+// parse compiles an expression into its syntax tree.
+func parse(expr string) (node, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{expr: expr, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, p.errorf(p.peek(), "empty expression")
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %s", describe(t))
+	}
+	return n, nil
+}
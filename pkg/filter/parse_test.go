@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLex(t *testing.T) {
+	tokens, err := lex(`(type==feat||!breaking) && author =~ 'bo\'t' not in`)
+	require.NoError(t, err)
+
+	kinds := make([]tokenKind, len(tokens))
+	for i, tok := range tokens {
+		kinds[i] = tok.kind
+	}
+	assert.Equal(t, []tokenKind{
+		tokLParen, tokIdent, tokEq, tokIdent, tokOr, tokNot, tokIdent, tokRParen,
+		tokAnd, tokIdent, tokMatch, tokString, tokNot, tokIn, tokEOF,
+	}, kinds)
+	assert.Equal(t, "bo't", tokens[11].value)
+	assert.Equal(t, 37, tokens[11].pos)
+}
+
+func TestParseErrors(t *testing.T) {
+	tcs := map[string]struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		"Empty":            {"", 0, "empty expression"},
+		"Single ampersand": {"type == feat & scope == api", 13, "unexpected '&', did you mean '&&'?"},
+		"Single equal":     {"type = feat", 5, "unexpected '=', did you mean '=='?"},
+		"Unterminated":     {`scope == "api`, 9, "unterminated string"},
+		"Unknown field":    {"type == feat && foo == bar", 16, "unknown field 'foo'"},
+		"Unknown type":     {"type in (feat, foo)", 15, "unknown commit type 'foo'"},
+		"Missing paren":    {"(type == feat", 13, "expected ')', found end of expression"},
+		"Trailing token":   {"type == feat)", 12, "unexpected ')'"},
+		"Missing value":    {"scope == )", 9, "expected a value, found ')'"},
+		"Bad operator":     {"breaking =~ yes", 9, "operator '=~' is not supported on boolean field 'breaking'"},
+		"Bad boolean":      {"breaking == yes", 12, "expected true or false, found 'yes'"},
+		"Bad date":         {"date > tomorrow", 7, "invalid date 'tomorrow'"},
+		"Bad regexp":       {`author =~ "("`, 10, "invalid regular expression: error parsing regexp: missing closing ): `(`"},
+		"Bad not":          {"type not feat", 9, "expected 'in' after 'not', found 'feat'"},
+		"Bad in list":      {"scope in (api ui)", 14, "expected ',' or ')', found 'ui'"},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			_, err := Compile(tc.expr)
+			require.Error(t, err)
+			perr, ok := err.(*ParseError)
+			require.True(t, ok)
+			assert.Equal(t, tc.pos, perr.Pos)
+			assert.Equal(t, tc.msg, perr.Msg)
+		})
+	}
+}
+
+func TestParseErrorString(t *testing.T) {
+	err := &ParseError{Expr: "type == foo", Pos: 8, Msg: "unknown commit type 'foo'"}
+	assert.Equal(t, "unknown commit type 'foo' at position 9\n\ttype == foo\n\t        ^", err.Error())
+}
@@ -0,0 +1,180 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokAnd
+	tokOr
+	tokNot
+	tokIn
+	tokEq
+	tokNeq
+	tokMatch
+	tokNotMatch
+	tokLt
+	tokLte
+	tokGt
+	tokGte
+)
+
+func (k tokenKind) String() string {
+	return [...]string{
+		"end of expression",
+		"identifier",
+		"string",
+		"'('",
+		"')'",
+		"','",
+		"'&&'",
+		"'||'",
+		"'!'",
+		"'in'",
+		"'=='",
+		"'!='",
+		"'=~'",
+		"'!~'",
+		"'<'",
+		"'<='",
+		"'>'",
+		"'>='",
+	}[k]
+}
+
+// keywords are the word aliases of the logical operators.
+var keywords = map[string]tokenKind{
+	"and": tokAnd,
+	"or":  tokOr,
+	"not": tokNot,
+	"in":  tokIn,
+}
+
+type token struct {
+	kind tokenKind
+	// Raw value (unquoted for strings)
+	value string
+	// Byte offset of the token in the expression
+	pos int
+}
+
+// lex splits an expression into tokens.
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokComma, pos: i})
+			i++
+		case c == '&' || c == '|':
+			if i+1 >= len(expr) || expr[i+1] != c {
+				return nil, &ParseError{Expr: expr, Pos: i, Msg: fmt.Sprintf("unexpected '%c', did you mean '%c%c'?", c, c, c)}
+			}
+			kind := tokAnd
+			if c == '|' {
+				kind = tokOr
+			}
+			tokens = append(tokens, token{kind: kind, pos: i})
+			i += 2
+		case c == '=':
+			if i+1 < len(expr) && expr[i+1] == '=' {
+				tokens = append(tokens, token{kind: tokEq, pos: i})
+			} else if i+1 < len(expr) && expr[i+1] == '~' {
+				tokens = append(tokens, token{kind: tokMatch, pos: i})
+			} else {
+				return nil, &ParseError{Expr: expr, Pos: i, Msg: "unexpected '=', did you mean '=='?"}
+			}
+			i += 2
+		case c == '!':
+			if i+1 < len(expr) && expr[i+1] == '=' {
+				tokens = append(tokens, token{kind: tokNeq, pos: i})
+				i += 2
+			} else if i+1 < len(expr) && expr[i+1] == '~' {
+				tokens = append(tokens, token{kind: tokNotMatch, pos: i})
+				i += 2
+			} else {
+				tokens = append(tokens, token{kind: tokNot, pos: i})
+				i++
+			}
+		case c == '<' || c == '>':
+			kind := tokLt
+			if c == '>' {
+				kind = tokGt
+			}
+			if i+1 < len(expr) && expr[i+1] == '=' {
+				tokens = append(tokens, token{kind: kind + 1, pos: i})
+				i += 2
+			} else {
+				tokens = append(tokens, token{kind: kind, pos: i})
+				i++
+			}
+		case c == '"' || c == '\'':
+			s, n, err := lexString(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, value: s, pos: i})
+			i += n
+		case isIdentChar(rune(c)):
+			start := i
+			for i < len(expr) && isIdentChar(rune(expr[i])) {
+				i++
+			}
+			word := expr[start:i]
+			if kind, ok := keywords[strings.ToLower(word)]; ok {
+				tokens = append(tokens, token{kind: kind, value: word, pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, value: word, pos: start})
+			}
+		default:
+			return nil, &ParseError{Expr: expr, Pos: i, Msg: fmt.Sprintf("unexpected character '%c'", c)}
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(expr)})
+	return tokens, nil
+}
+
+// lexString reads a quoted string starting at expr[start]. It returns the unquoted value and the number of bytes consumed.
+func lexString(expr string, start int) (string, int, error) {
+	quote := expr[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '\\' && i+1 < len(expr) && (expr[i+1] == quote || expr[i+1] == '\\'):
+			// Only quotes and backslashes are escaped, so that regular expressions can be written as is
+			i++
+			sb.WriteByte(expr[i])
+		case c == quote:
+			return sb.String(), i - start + 1, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, &ParseError{Expr: expr, Pos: start, Msg: "unterminated string"}
+}
+
+func isIdentChar(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) ||
+		r == '_' || r == '-' || r == '.' || r == '/' || r == ':' || r == '@'
+}
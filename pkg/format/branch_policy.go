@@ -0,0 +1,118 @@
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Default pattern of branch prefixes: an issue ID (e.g. PROJ-12 or 42)
+	DefaultBranchPrefix = regexp.MustCompile(`^(?:[A-Za-z][A-Za-z0-9]*-)?[0-9]+$`)
+	// Default pattern of branches the policy ignores
+	DefaultBranchIgnore = regexp.MustCompile(`^(?:HEAD|main|master|develop)$`)
+)
+
+// BranchPolicy describes the allowed branch names.
+type BranchPolicy struct {
+	// Allowed branch types
+	Types []string
+	// Type rewrite map, used to fix branch types
+	Rewrite map[string]string
+	// Pattern of branch prefixes, user branches excepted
+	Prefix *regexp.Regexp
+	// Prefix of user branches, usually the git user name
+	User string
+	// Branches the policy does not apply to
+	Ignore *regexp.Regexp
+}
+
+// BranchViolation describes a branch that does not follow the policy.
+type BranchViolation struct {
+	// Offending branch name
+	Branch string
+	// Problem description
+	Msg string
+	// Conforming branch, nil if the branch cannot be fixed automatically
+	Fix *TugBranch
+}
+
+func (bv *BranchViolation) Error() string {
+	return fmt.Sprintf("%s: %s", bv.Branch, bv.Msg)
+}
+
+// DefaultBranchPolicy returns a policy allowing commit types and user branches, prefixed by an issue ID or the user name.
+func DefaultBranchPolicy(user string) *BranchPolicy {
+	return &BranchPolicy{
+		Types:   append(AllCommitType(), "user", "users"),
+		Rewrite: DefaultTypeRewrite,
+		Prefix:  DefaultBranchPrefix,
+		User:    user,
+		Ignore:  DefaultBranchIgnore,
+	}
+}
+
+// Check returns a *BranchViolation if the branch does not follow the policy, nil otherwise.
+func (bp *BranchPolicy) Check(name string) *BranchViolation {
+	if bp.Ignore != nil && bp.Ignore.MatchString(name) {
+		return nil
+	}
+	tb, err := ParseBranch(name)
+	if err != nil || tb.Description == "" {
+		return &BranchViolation{Branch: name, Msg: "expected <type>/[<prefix>/]<description>"}
+	}
+
+	var problems []string
+	fixable := true
+
+	// Type
+	if !bp.isType(tb.Type) {
+		fixed := tb.WithType(tb.Type, bp.Rewrite)
+		if bp.isType(fixed.Type) {
+			problems = append(problems, fmt.Sprintf("type '%s' should be '%s'", tb.Type, fixed.Type))
+			tb = fixed
+		} else {
+			problems = append(problems, fmt.Sprintf("unknown type '%s', expected one of %s", tb.Type, strings.Join(bp.Types, ", ")))
+			fixable = false
+		}
+	}
+
+	// Prefix
+	if tb.Type == "user" || tb.Type == "users" {
+		if bp.User != "" && tb.Prefix != bp.User && tb.Prefix != sanitizeBranch(bp.User) {
+			if tb.Prefix == "" {
+				problems = append(problems, fmt.Sprintf("user branches must be prefixed by '%s'", bp.User))
+			} else {
+				problems = append(problems, fmt.Sprintf("prefix '%s' should be '%s'", tb.Prefix, bp.User))
+				fixable = false
+			}
+			tb.Prefix = bp.User
+		}
+	} else if tb.Prefix != "" && bp.Prefix != nil && !bp.Prefix.MatchString(tb.Prefix) {
+		problems = append(problems, fmt.Sprintf("prefix '%s' does not match '%s'", tb.Prefix, bp.Prefix))
+		fixable = false
+	}
+
+	// Sanitization
+	if len(problems) == 0 && tb.String() != name {
+		problems = append(problems, fmt.Sprintf("name is not sanitized, expected '%s'", tb.String()))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	bv := &BranchViolation{Branch: name, Msg: strings.Join(problems, ", ")}
+	if fixable && tb.String() != name {
+		bv.Fix = &tb
+	}
+	return bv
+}
+
+func (bp *BranchPolicy) isType(t string) bool {
+	for _, allowed := range bp.Types {
+		if t == allowed {
+			return true
+		}
+	}
+	return false
+}
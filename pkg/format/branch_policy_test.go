@@ -0,0 +1,47 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchPolicyCheck(t *testing.T) {
+	bp := DefaultBranchPolicy("alice")
+
+	tcs := map[string]struct {
+		branch string
+		msg    string
+		fix    string
+	}{
+		"Ignored":       {"main", "", ""},
+		"Valid":         {"feat/a-foo-feature", "", ""},
+		"Valid issue":   {"fix/PROJ-12/login-timeout", "", ""},
+		"Valid user":    {"user/alice/my-branch", "", ""},
+		"No type":       {"my-branch", "expected <type>/[<prefix>/]<description>", ""},
+		"Rewrite":       {"feature/PROJ-12/login", "type 'feature' should be 'feat'", "feat/PROJ-12/login"},
+		"Unknown type":  {"foo/bar", "unknown type 'foo', expected one of build, ci, chore, docs, feat, fix, perf, refactor, style, test, auto, revert, user, users", ""},
+		"Bad prefix":    {"feat/some/thing", "prefix 'some' does not match '^(?:[A-Za-z][A-Za-z0-9]*-)?[0-9]+$'", ""},
+		"Missing user":  {"user/my-branch", "user branches must be prefixed by 'alice'", "user/alice/my-branch"},
+		"Other user":    {"user/bob/my-branch", "prefix 'bob' should be 'alice'", ""},
+		"Not sanitized": {"fix/Login_Timeout", "name is not sanitized, expected 'fix/login_timeout'", "fix/login_timeout"},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			bv := bp.Check(tc.branch)
+			if tc.msg == "" {
+				assert.Nil(t, bv)
+				return
+			}
+			if assert.NotNil(t, bv) {
+				assert.Equal(t, tc.msg, bv.Msg)
+				if tc.fix == "" {
+					assert.Nil(t, bv.Fix)
+				} else if assert.NotNil(t, bv.Fix) {
+					assert.Equal(t, tc.fix, bv.Fix.String())
+				}
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ const (
 	StyleCommit
 	TestCommit
 	AutoCommit
+	RevertCommit
 )
 
 func (b CommitType) String() string {
@@ -41,6 +42,7 @@ func (b CommitType) String() string {
 		"style",
 		"test",
 		"auto",
+		"revert",
 	}[b]
 }
 
@@ -61,6 +63,8 @@ func (b CommitType) ColorString() string {
 		colorize("refactor", 30),
 		colorize("style", 6),
 		colorize("test", 11),
+		colorize("auto", 8),
+		colorize("revert", 9),
 	}[b]
 }
 
@@ -77,6 +81,7 @@ func AllCommitType() []string {
 		StyleCommit.String(),
 		TestCommit.String(),
 		AutoCommit.String(),
+		RevertCommit.String(),
 	}
 }
 
@@ -92,6 +97,7 @@ var (
 	styleCommitRe    = regexp.MustCompile(`(?i)^s(?:tyles?)?$`)
 	testCommitRe     = regexp.MustCompile(`(?i)^t(?:ests?)?$`)
 	autoCommitRe     = regexp.MustCompile(`(?i)^auto$`)
+	revertCommitRe   = regexp.MustCompile(`(?i)^reverts?$`)
 )
 
 type CommitMessageOption struct {
@@ -127,6 +133,64 @@ func (cmo *CommitMessageOption) Check() error {
 	return nil
 }
 
+// CoAuthors returns the co-authors credited in Co-authored-by footers, as 'name <email>'.
+func (cmo *CommitMessageOption) CoAuthors() []string {
+	return cmo.FooterValues(COAUTHOR_FOOTER)
+}
+
+// AddFooter appends a footer unless it is already present.
+func (cmo *CommitMessageOption) AddFooter(footer string) {
+	for _, f := range cmo.Footers {
+		if f == footer {
+			return
+		}
+	}
+	cmo.Footers = append(cmo.Footers, footer)
+}
+
+// FooterValues returns the values of the footers with the given key (case insensitive).
+func (cmo *CommitMessageOption) FooterValues(key string) []string {
+	var values []string
+	for _, f := range cmo.Footers {
+		k, v := splitFooter(f)
+		if strings.EqualFold(k, key) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// SignOffs returns the identities of the Signed-off-by footers of a raw message, conventional or not.
+func SignOffs(msg string) []string {
+	var ids []string
+	for _, l := range strings.Split(msg, "\n")[1:] {
+		if k, v := splitFooter(strings.TrimSpace(l)); strings.EqualFold(k, SIGNOFF_FOOTER) {
+			ids = append(ids, strings.TrimSpace(v))
+		}
+	}
+	return ids
+}
+
+// ParseIdent splits a 'name <email>' identity. The email is empty if there is none.
+func ParseIdent(ident string) (name string, email string) {
+	lt, gt := strings.Index(ident, "<"), strings.LastIndex(ident, ">")
+	if lt < 0 || gt < lt {
+		return strings.TrimSpace(ident), ""
+	}
+	return strings.TrimSpace(ident[:lt]), strings.TrimSpace(ident[lt+1 : gt])
+}
+
+// splitFooter splits a footer line into its key and value.
+func splitFooter(f string) (string, string) {
+	if i := strings.Index(f, ": "); i > 0 {
+		return f[:i], f[i+2:]
+	}
+	if i := strings.Index(f, " #"); i > 0 {
+		return f[:i], f[i+1:]
+	}
+	return f, ""
+}
+
 // Format commit message according to https://www.conventionalcommits.org/en/v1.0.0/
 func CommitMessage(o *CommitMessageOption) string {
 	msg := o.Ctype.String()
@@ -155,6 +219,34 @@ func CommitMessage(o *CommitMessageOption) string {
 	return msg
 }
 
+const (
+	// Footer key crediting a co-author
+	COAUTHOR_FOOTER = "Co-authored-by"
+	// Footer key certifying the Developer Certificate of Origin
+	SIGNOFF_FOOTER = "Signed-off-by"
+)
+
+var revertedRe = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-f]{7,40})\b`)
+
+// RevertMessage returns the message of a commit reverting the commit with the given header and ID, as git revert does.
+// The reverted commit is also referenced in a Refs footer.
+func RevertMessage(header string, id string) string {
+	return CommitMessage(&CommitMessageOption{
+		Ctype:       RevertCommit,
+		Description: header,
+		Body:        fmt.Sprintf("This reverts commit %s.", id),
+		Footers:     []string{"Refs: " + id},
+	})
+}
+
+// RevertedCommit returns the ID of the commit reverted by a commit message, or an empty string if it is not a revert.
+func RevertedCommit(msg string) string {
+	if m := revertedRe.FindStringSubmatch(msg); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
 // Extract type from string
 func FindCommitType(str string) CommitType {
 	s := []byte(str)
@@ -181,11 +273,66 @@ func FindCommitType(str string) CommitType {
 		return TestCommit
 	case autoCommitRe.Match(s):
 		return AutoCommit
+	case revertCommitRe.Match(s):
+		return RevertCommit
 	default:
 		return NilCommit
 	}
 }
 
+var footerRe = regexp.MustCompile(`^[\w-]+(?: #|: )`)
+
+// SplitHeader splits a message into its header line and the rest of it, kept as is (starting with the line break).
+func SplitHeader(msg string) (header string, rest string) {
+	if i := strings.Index(msg, "\n"); i >= 0 {
+		return msg[:i], msg[i:]
+	}
+	return msg, ""
+}
+
+// AppendFooters appends the footers missing from msg, in the trailing footers paragraph if there is one.
+// The rest of the message is kept as is.
+func AppendFooters(msg string, footers ...string) string {
+	trimmed := strings.TrimRight(msg, "\n")
+	lines := strings.Split(trimmed, "\n")
+	present := map[string]bool{}
+	for _, l := range lines {
+		present[l] = true
+	}
+	var missing []string
+	for _, f := range footers {
+		if !present[f] {
+			present[f] = true
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) == 0 {
+		return msg
+	}
+
+	if trimmed == "" {
+		return strings.Join(missing, "\n")
+	}
+
+	// The last paragraph is a footers one if all its lines are footers, the header never is
+	start := len(lines)
+	for start > 0 && lines[start-1] != "" {
+		start--
+	}
+	inFooters := start > 0
+	for _, l := range lines[start:] {
+		if !footerRe.MatchString(l) {
+			inFooters = false
+			break
+		}
+	}
+	sep := "\n\n"
+	if inFooters {
+		sep = "\n"
+	}
+	return trimmed + sep + strings.Join(missing, "\n")
+}
+
 func ParseCommitMsg(msg string) *CommitMessageOption {
 	lines := strings.Split(msg, "\n")
 
@@ -209,9 +356,8 @@ func ParseCommitMsg(msg string) *CommitMessageOption {
 	}
 
 	// Body and footers
-	re = regexp.MustCompile(`(?m)^\w+(?: #|: )`)
 	for _, l := range lines[1:] {
-		if re.MatchString(l) {
+		if footerRe.MatchString(l) {
 			cmo.Footers = append(cmo.Footers, l)
 		} else {
 			cmo.Body += l
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCommitMessage(t *testing.T) {
@@ -90,6 +91,14 @@ func TestCMOOverwrite(t *testing.T) {
 	}
 }
 
+func TestCMOFooterValues(t *testing.T) {
+	cmo := &CommitMessageOption{Footers: []string{"Refs: PROJ-12", "refs #42", "Reviewed-by: Bob", "Refs: PROJ-13"}}
+
+	assert.Equal(t, []string{"PROJ-12", "#42", "PROJ-13"}, cmo.FooterValues("Refs"))
+	assert.Equal(t, []string{"Bob"}, cmo.FooterValues("reviewed-by"))
+	assert.Nil(t, cmo.FooterValues("Closes"))
+}
+
 func TestFindCommitType(t *testing.T) {
 	tcs := map[string]struct {
 		str      string
@@ -140,6 +149,9 @@ func TestFindCommitType(t *testing.T) {
 		"Tests": {"tests", TestCommit},
 
 		"Auto": {"auto", AutoCommit},
+
+		"Revert":  {"revert", RevertCommit},
+		"Reverts": {"Reverts", RevertCommit},
 	}
 
 	for name, tc := range tcs {
@@ -165,6 +177,8 @@ func TestParseCommitMsg(t *testing.T) {
 			&CommitMessageOption{Ctype: FeatureCommit, Description: "message description", Body: "Commit body"}},
 		"With footers": {"feat: message description\n\nCommit body\n\nFooter: 1\nFooter #2",
 			&CommitMessageOption{Ctype: FeatureCommit, Description: "message description", Body: "Commit body", Footers: []string{"Footer: 1", "Footer #2"}}},
+		"With hyphenated footers": {"fix: message\n\nCo-authored-by: Bob <bob@example.com>\nReviewed-by: Carol",
+			&CommitMessageOption{Ctype: FixCommit, Description: "message", Footers: []string{"Co-authored-by: Bob <bob@example.com>", "Reviewed-by: Carol"}}},
 	}
 
 	for name, tc := range tcs {
@@ -209,3 +223,71 @@ func TestNextBump(t *testing.T) {
 		})
 	}
 }
+
+func TestRevertMessage(t *testing.T) {
+	msg := RevertMessage("feat(api): add endpoint", "0123abc")
+	assert.Equal(t, "revert: feat(api): add endpoint\n\nThis reverts commit 0123abc.\n\nRefs: 0123abc", msg)
+	co := ParseCommitMsg(msg)
+	require.NotNil(t, co)
+	assert.Equal(t, RevertCommit, co.Ctype)
+	assert.Equal(t, "feat(api): add endpoint", co.Description)
+	assert.Equal(t, []string{"Refs: 0123abc"}, co.Footers)
+	assert.Equal(t, "0123abc", RevertedCommit(msg))
+}
+
+func TestRevertedCommit(t *testing.T) {
+	assert.Equal(t, "0123abcd", RevertedCommit("Revert \"feat: foo\"\n\nThis reverts commit 0123abcd."))
+	assert.Equal(t, "", RevertedCommit("fix: this reverts commit 0123abcd"))
+	assert.Equal(t, "", RevertedCommit("feat: foo"))
+}
+
+func TestCoAuthors(t *testing.T) {
+	co := &CommitMessageOption{Footers: []string{"Refs: 12"}}
+	co.AddFooter("Co-authored-by: Bob <bob@example.com>")
+	co.AddFooter("Co-authored-by: Bob <bob@example.com>")
+	co.AddFooter("co-authored-by: Carol <carol@example.com>")
+	assert.Equal(t, []string{"Bob <bob@example.com>", "Carol <carol@example.com>"}, co.CoAuthors())
+}
+
+func TestParseIdent(t *testing.T) {
+	name, email := ParseIdent("Bob Doe <bob@example.com>")
+	assert.Equal(t, "Bob Doe", name)
+	assert.Equal(t, "bob@example.com", email)
+	name, email = ParseIdent(" Bob ")
+	assert.Equal(t, "Bob", name)
+	assert.Equal(t, "", email)
+}
+
+func TestSignOffs(t *testing.T) {
+	assert.Empty(t, SignOffs("feat: no sign-off\n\nSigned-off-by is in the body"))
+	assert.Equal(t, []string{"Bob <bob@example.com>", "Carol <carol@example.com>"},
+		SignOffs("Not conventional\n\nBody\n\nSigned-off-by: Bob <bob@example.com>\nsigned-off-by: Carol <carol@example.com>"))
+}
+
+func TestSplitHeader(t *testing.T) {
+	header, rest := SplitHeader("feat: x\n\nBody\n")
+	assert.Equal(t, "feat: x", header)
+	assert.Equal(t, "\n\nBody\n", rest)
+	header, rest = SplitHeader("feat: x")
+	assert.Equal(t, "feat: x", header)
+	assert.Equal(t, "", rest)
+}
+
+func TestAppendFooters(t *testing.T) {
+	tcs := map[string]struct {
+		msg      string
+		footers  []string
+		expected string
+	}{
+		"Header only":       {"feat: x", []string{"Refs: 1"}, "feat: x\n\nRefs: 1"},
+		"Body":              {"feat: x\n\nSome body\nText: not a footer paragraph\n", []string{"Refs: 1"}, "feat: x\n\nSome body\nText: not a footer paragraph\n\nRefs: 1"},
+		"Footers paragraph": {"feat: x\n\nBody\n\nRefs: 1\n", []string{"Signed-off-by: A <a@b.c>"}, "feat: x\n\nBody\n\nRefs: 1\nSigned-off-by: A <a@b.c>"},
+		"Already present":   {"feat: x\n\nRefs: 1\n", []string{"Refs: 1"}, "feat: x\n\nRefs: 1\n"},
+		"Empty":             {"", []string{"Refs: 1"}, "Refs: 1"},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, AppendFooters(tc.msg, tc.footers...))
+		})
+	}
+}
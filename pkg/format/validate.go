@@ -0,0 +1,113 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// HeaderError is returned when a commit header does not strictly follow the conventional commit specification.
+type HeaderError struct {
+	// The invalid header
+	Header string
+	// Rune offset of the problem in the header
+	Pos int
+	// Problem description
+	Msg string
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos+1)
+}
+
+// Pointer returns the header with a caret under the problem.
+func (e *HeaderError) Pointer() string {
+	return fmt.Sprintf("%s\n%s^", e.Header, strings.Repeat(" ", e.Pos))
+}
+
+// ValidateHeader strictly validates a commit header.
+// Unlike ParseCommitMsg, it rejects unknown or abbreviated types, empty scopes,
+// blank descriptions and misplaced breaking change markers.
+// The returned error is a *HeaderError, or nil if the header is valid.
+func ValidateHeader(header string) error {
+	h := []rune(header)
+	fail := func(pos int, format string, a ...interface{}) error {
+		return &HeaderError{Header: header, Pos: pos, Msg: fmt.Sprintf(format, a...)}
+	}
+
+	// Type
+	pos := 0
+	for pos < len(h) && (unicode.IsLetter(h[pos]) || unicode.IsDigit(h[pos]) || h[pos] == '_') {
+		pos++
+	}
+	ctype := string(h[:pos])
+	if ctype == "" {
+		return fail(0, "missing commit type")
+	}
+	if !isCommitType(ctype) {
+		if found := FindCommitType(ctype); found != NilCommit {
+			return fail(0, "unknown commit type '%s', did you mean '%s'?", ctype, found)
+		}
+		return fail(0, "unknown commit type '%s', expected one of %s", ctype, strings.Join(AllCommitType(), ", "))
+	}
+
+	// Breaking change marker before the scope
+	if pos < len(h) && h[pos] == '!' && pos+1 < len(h) && h[pos+1] == '(' {
+		return fail(pos, "malformed breaking change marker, it must follow the scope")
+	}
+
+	// Scope
+	if pos < len(h) && h[pos] == '(' {
+		end := pos + 1
+		for end < len(h) && h[end] != ')' {
+			end++
+		}
+		if end >= len(h) {
+			return fail(pos, "unclosed scope")
+		}
+		if strings.TrimSpace(string(h[pos+1:end])) == "" {
+			return fail(pos+1, "empty scope")
+		}
+		pos = end + 1
+	}
+
+	// Breaking change marker
+	if pos < len(h) && h[pos] == '!' {
+		pos++
+	}
+	if pos >= len(h) || h[pos] != ':' {
+		// Doubled marker or marker separated by spaces (e.g. 'feat!!: x' or 'feat !: x')
+		bang := pos
+		for bang < len(h) && unicode.IsSpace(h[bang]) {
+			bang++
+		}
+		if bang < len(h) && h[bang] == '!' {
+			return fail(bang, "malformed breaking change marker, expected a single '!' right before ':'")
+		}
+		return fail(pos, "expected ':' after the commit type")
+	}
+	pos++
+
+	// Description
+	if pos >= len(h) || h[pos] != ' ' {
+		return fail(pos, "expected a space after ':'")
+	}
+	pos++
+	if strings.TrimSpace(string(h[pos:])) == "" {
+		return fail(pos, "empty description")
+	}
+	if unicode.IsSpace(h[pos]) {
+		return fail(pos, "description must not start with a space")
+	}
+	return nil
+}
+
+// isCommitType returns true if s is the name of a commit type (case insensitive).
+func isCommitType(s string) bool {
+	for _, t := range AllCommitType() {
+		if strings.EqualFold(s, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateHeader(t *testing.T) {
+	tcs := map[string]struct {
+		header string
+		pos    int
+		msg    string
+	}{
+		"Valid":             {"feat: add a feature", -1, ""},
+		"Valid scope":       {"fix(api)!: handle nil", -1, ""},
+		"Valid case":        {"Docs: update readme", -1, ""},
+		"Missing type":      {": nothing", 0, "missing commit type"},
+		"Unknown type":      {"foo: bar", 0, "unknown commit type 'foo', expected one of build, ci, chore, docs, feat, fix, perf, refactor, style, test, auto, revert"},
+		"Alias":             {"feature: x", 0, "unknown commit type 'feature', did you mean 'feat'?"},
+		"Digits":            {"feature123: x", 0, "unknown commit type 'feature123', expected one of build, ci, chore, docs, feat, fix, perf, refactor, style, test, auto, revert"},
+		"Empty scope":       {"feat(): x", 5, "empty scope"},
+		"Blank scope":       {"feat(  ): x", 5, "empty scope"},
+		"Unclosed scope":    {"feat(api: x", 4, "unclosed scope"},
+		"Bang before scope": {"feat!(api): x", 4, "malformed breaking change marker, it must follow the scope"},
+		"Double bang":       {"feat!!: x", 5, "malformed breaking change marker, expected a single '!' right before ':'"},
+		"Spaced bang":       {"feat(api) !: x", 10, "malformed breaking change marker, expected a single '!' right before ':'"},
+		"Missing colon":     {"feat add", 4, "expected ':' after the commit type"},
+		"No description":    {"feat:", 5, "expected a space after ':'"},
+		"No space":          {"feat:x", 5, "expected a space after ':'"},
+		"Blank description": {"feat:    ", 6, "empty description"},
+		"Leading space":     {"feat:  x", 6, "description must not start with a space"},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateHeader(tc.header)
+			if tc.pos < 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.IsType(t, &HeaderError{}, err) {
+				herr := err.(*HeaderError)
+				assert.Equal(t, tc.pos, herr.Pos)
+				assert.Equal(t, tc.msg, herr.Msg)
+			}
+		})
+	}
+}
+
+func TestHeaderError(t *testing.T) {
+	err := &HeaderError{Header: "feat(): x", Pos: 5, Msg: "empty scope"}
+	assert.EqualError(t, err, "empty scope at position 6")
+	assert.Equal(t, "feat(): x\n     ^", err.Pointer())
+}
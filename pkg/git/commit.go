@@ -1,6 +1,11 @@
 package git
 
-import git "github.com/libgit2/git2go/v33"
+import (
+	"errors"
+	"strings"
+
+	git "github.com/libgit2/git2go/v33"
+)
 
 // RepoTree return the current index tree
 func RepoTree(r *git.Repository) (*git.Tree, error) {
@@ -20,12 +25,28 @@ func RepoTree(r *git.Repository) (*git.Tree, error) {
 }
 
 // Commit creates a new commit with the current tree.
+// If a merge is in progress, the merged heads are recorded as parents. A cherry-pick keeps the original author.
+// The state of the merge, cherry-pick or revert is cleaned up once committed.
 func Commit(r *git.Repository, msg string) (*git.Commit, error) {
+	idx, err := r.Index()
+	if err != nil {
+		return nil, err
+	}
+	if idx.HasConflicts() {
+		return nil, errors.New("Committing is not possible because you have unmerged files")
+	}
 	// Signature
 	sig, err := r.DefaultSignature()
 	if err != nil {
 		return nil, err
 	}
+	author, err := StateAuthor(r)
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		author = sig
+	}
 	// Tree
 	tree, err := RepoTree(r)
 	if err != nil {
@@ -41,14 +62,39 @@ func Commit(r *git.Repository, msg string) (*git.Commit, error) {
 		}
 		parents = append(parents, headRef)
 	}
+	merged, err := MergeHeads(r)
+	if err != nil {
+		return nil, err
+	}
+	parents = append(parents, merged...)
 
-	oid, err := r.CreateCommit("HEAD", sig, sig, msg, tree, parents...)
+	oid, err := r.CreateCommit("", author, sig, msg, tree, parents...)
 	if err != nil {
 		return nil, err
 	}
+	op := "commit"
+	switch {
+	case len(parents) == 0:
+		op = "commit (initial)"
+	case len(parents) > 1:
+		op = "commit (merge)"
+	}
+	if err := UpdateHead(r, oid, ReflogMessage(op, summary(msg))); err != nil {
+		return nil, err
+	}
+	if r.State() != git.RepositoryStateNone {
+		if err := r.StateCleanup(); err != nil {
+			return nil, err
+		}
+	}
 	return r.LookupCommit(oid)
 }
 
+// summary returns the first line of a commit message.
+func summary(msg string) string {
+	return strings.SplitN(strings.TrimSpace(msg), "\n", 2)[0]
+}
+
 // Amend amends the HEAD commit
 func Amend(ca *git.Commit, msg string) (*git.Commit, error) {
 	r := ca.Object.Owner()
@@ -62,9 +108,129 @@ func Amend(ca *git.Commit, msg string) (*git.Commit, error) {
 	if err != nil {
 		return nil, err
 	}
-	oid, err := ca.Amend("HEAD", ca.Author(), sig, msg, tree)
+	oid, err := ca.Amend("", ca.Author(), sig, msg, tree)
+	if err != nil {
+		return nil, err
+	}
+	if err := UpdateHead(r, oid, ReflogMessage("commit (amend)", summary(msg))); err != nil {
+		return nil, err
+	}
+	return r.LookupCommit(oid)
+}
+
+// CommitDiff returns the diff introduced by a commit against its first parent.
+// Root commits are compared to an empty tree.
+func CommitDiff(c *git.Commit) (*git.Diff, error) {
+	r := c.Object.Owner()
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *git.Tree
+	if c.ParentCount() > 0 {
+		parentTree, err = c.Parent(0).Tree()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r.DiffTreeToTree(parentTree, tree, nil)
+}
+
+// CommitFiles returns the paths of the files touched by a commit.
+func CommitFiles(c *git.Commit) ([]string, error) {
+	diff, err := CommitDiff(c)
+	if err != nil {
+		return nil, err
+	}
+	defer diff.Free()
+	return DiffFiles(diff)
+}
+
+// DiffFiles returns the paths of the files touched by a diff.
+func DiffFiles(diff *git.Diff) ([]string, error) {
+	n, err := diff.NumDeltas()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		delta, err := diff.Delta(i)
+		if err != nil {
+			return nil, err
+		}
+		p := delta.NewFile.Path
+		if p == "" {
+			p = delta.OldFile.Path
+		}
+		files = append(files, p)
+	}
+	return files, nil
+}
+
+// CherryPick applies the changes introduced by c on top of HEAD and commits them with the original message and author.
+// If the cherry-pick conflicts, the repository is left in cherry-pick state and an error is returned.
+func CherryPick(r *git.Repository, c *git.Commit) (*git.Commit, error) {
+	opts, err := git.DefaultCherrypickOptions()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Cherrypick(c, opts); err != nil {
+		return nil, err
+	}
+	idx, err := r.Index()
+	if err != nil {
+		return nil, err
+	}
+	if idx.HasConflicts() {
+		return nil, errors.New("Cherry-pick has conflicts, resolve them before committing")
+	}
+
+	sig, err := r.DefaultSignature()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := RepoTree(r)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	parent, err := r.LookupCommit(head.Target())
+	if err != nil {
+		return nil, err
+	}
+	oid, err := r.CreateCommit("", c.Author(), sig, c.Message(), tree, parent)
 	if err != nil {
 		return nil, err
 	}
+	if err := UpdateHead(r, oid, ReflogMessage("cherry-pick", c.Summary())); err != nil {
+		return nil, err
+	}
+	if err := r.StateCleanup(); err != nil {
+		return nil, err
+	}
 	return r.LookupCommit(oid)
 }
+
+// Revert applies the reverse of the changes introduced by c to the index and the working tree, and leaves the repository
+// in revert state, to be concluded by Commit. mainline is the parent to revert to for merge commits, 0 otherwise.
+func Revert(r *git.Repository, c *git.Commit, mainline uint) error {
+	opts, err := git.DefaultRevertOptions()
+	if err != nil {
+		return err
+	}
+	opts.Mainline = mainline
+	if err := r.Revert(c, &opts); err != nil {
+		return err
+	}
+	idx, err := r.Index()
+	if err != nil {
+		return err
+	}
+	if idx.HasConflicts() {
+		return errors.New("Revert has conflicts, resolve them before committing")
+	}
+	return nil
+}
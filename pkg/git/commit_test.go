@@ -1,10 +1,12 @@
 package git
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -42,3 +44,51 @@ func TestAmend(t *testing.T) {
 	assert.Equal(t, "bar", headc.Message())
 	assert.Equal(t, headc.Id(), amendc.Id())
 }
+
+func TestCommitFiles(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	f1 := test.NewFile(t, r)
+	test.StageFile(t, f1, r)
+	c1, err := Commit(r, "feat: initial commit")
+	require.NoError(t, err)
+	files, err := CommitFiles(c1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Base(f1.Name())}, files)
+
+	f2 := test.NewFile(t, r)
+	test.StageFile(t, f2, r)
+	c2, err := Commit(r, "feat: second commit")
+	require.NoError(t, err)
+	files, err = CommitFiles(c2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Base(f2.Name())}, files)
+}
+
+func TestCherryPick(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	base, err := Commit(r, "feat: initial commit")
+	require.NoError(t, err)
+	test.StageNewFile(t, r)
+	pick, err := Commit(r, "feat: picked commit")
+	require.NoError(t, err)
+	// Move HEAD back to the initial commit
+	_, err = r.References.Create("refs/heads/other", base.Id(), false, "")
+	require.NoError(t, err)
+	require.NoError(t, r.SetHead("refs/heads/other"))
+	tree, err := base.Tree()
+	require.NoError(t, err)
+	require.NoError(t, r.CheckoutTree(tree, &git.CheckoutOpts{Strategy: git.CheckoutForce}))
+
+	c, err := CherryPick(r, pick)
+	assert.NoError(t, err)
+	assert.Equal(t, "feat: picked commit", c.Message())
+	assert.Equal(t, base.Id(), c.ParentId(0))
+	assert.Equal(t, pick.TreeId(), c.TreeId())
+	assert.Equal(t, git.RepositoryStateNone, r.State())
+}
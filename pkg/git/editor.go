@@ -0,0 +1,42 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Editor used when none is configured, as git does
+	DEFAULT_EDITOR = "vi"
+)
+
+// Editor returns the editor git would use, from GIT_EDITOR, core.editor, VISUAL then EDITOR.
+func Editor(r *git.Repository) string {
+	if e := os.Getenv("GIT_EDITOR"); e != "" {
+		return e
+	}
+	if c, err := r.Config(); err == nil {
+		defer c.Free()
+		if e, _ := c.LookupString("core.editor"); e != "" {
+			return e
+		}
+	}
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := os.Getenv(env); e != "" {
+			return e
+		}
+	}
+	return DEFAULT_EDITOR
+}
+
+// LaunchEditor opens file in editor and waits for it to exit.
+// As in git, the editor is run by the shell so that it may contain arguments.
+func LaunchEditor(editor string, file string) error {
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, file)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditor(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+
+	for _, env := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		defer os.Setenv(env, os.Getenv(env))
+		require.NoError(t, os.Unsetenv(env))
+	}
+	assert.Equal(t, DEFAULT_EDITOR, Editor(r))
+
+	require.NoError(t, os.Setenv("EDITOR", "nano"))
+	assert.Equal(t, "nano", Editor(r))
+	require.NoError(t, os.Setenv("VISUAL", "code --wait"))
+	assert.Equal(t, "code --wait", Editor(r))
+
+	c, err := r.Config()
+	require.NoError(t, err)
+	defer c.Free()
+	require.NoError(t, c.SetString("core.editor", "emacs"))
+	assert.Equal(t, "emacs", Editor(r))
+
+	require.NoError(t, os.Setenv("GIT_EDITOR", "vim"))
+	assert.Equal(t, "vim", Editor(r))
+}
+
+func TestLaunchEditor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "turbogit-test-editor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "COMMIT_EDITMSG")
+	require.NoError(t, ioutil.WriteFile(file, []byte("feat: draft\n"), 0644))
+	require.NoError(t, LaunchEditor(`sed -i -e 's/draft/edited/'`, file))
+	raw, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: edited\n", string(raw))
+
+	assert.Error(t, LaunchEditor("false", file))
+}
@@ -5,13 +5,42 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path"
+	"path/filepath"
+
+	git "github.com/libgit2/git2go/v33"
 )
 
 // Hooks
 
-func hookCmd(root string, hook string) (*exec.Cmd, error) {
-	script := path.Join(root, ".git", "hooks", hook)
+// HooksDir returns the directory git looks hooks up in, honoring core.hooksPath.
+func HooksDir(r *git.Repository) (string, error) {
+	c, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	defer c.Free()
+	if hp, _ := c.LookupString("core.hooksPath"); hp != "" {
+		if filepath.IsAbs(hp) {
+			return filepath.Clean(hp), nil
+		}
+		if r.IsBare() {
+			// Relative hooks paths are relative to the repository of a bare repository
+			return filepath.Join(r.Path(), hp), nil
+		}
+		// Relative hooks paths are relative to the working tree
+		return filepath.Join(r.Workdir(), hp), nil
+	}
+	return r.ItemPath(git.RepositoryItemHooks)
+}
+
+// hookCmd returns the command running a hook from the hooks directory, or nil if the hook does not exist.
+// Like git, hooks run at the root of the working tree, or in the repository if it is bare.
+func hookCmd(r *git.Repository, hook string) (*exec.Cmd, error) {
+	dir, err := HooksDir(r)
+	if err != nil {
+		return nil, err
+	}
+	script := filepath.Join(dir, hook)
 	info, err := os.Stat(script)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -20,7 +49,11 @@ func hookCmd(root string, hook string) (*exec.Cmd, error) {
 		return nil, err
 	}
 	if info.IsDir() {
-		return nil, fmt.Errorf("Hook .git/hooks/%s is a directory, it should be an executable file.", hook)
+		return nil, fmt.Errorf("Hook %s is a directory, it should be an executable file.", script)
+	}
+	root := r.Workdir()
+	if r.IsBare() {
+		root = r.Path()
 	}
 	return &exec.Cmd{
 		Dir:    root,
@@ -31,8 +64,8 @@ func hookCmd(root string, hook string) (*exec.Cmd, error) {
 	}, nil
 }
 
-func noArgHook(root string, hook string) error {
-	cmd, err := hookCmd(root, hook)
+func noArgHook(r *git.Repository, hook string) error {
+	cmd, err := hookCmd(r, hook)
 	if err != nil {
 		return err
 	}
@@ -44,11 +77,11 @@ func noArgHook(root string, hook string) error {
 	return cmd.Run()
 }
 
-func fileHook(root string, hook string, initial string) (out string, err error) {
+func fileHook(r *git.Repository, hook string, initial string) (out string, err error) {
 	out = initial
-	cmd, err := hookCmd(root, hook)
-	if cmd == nil {
-		return initial, nil
+	cmd, err := hookCmd(r, hook)
+	if err != nil || cmd == nil {
+		return
 	}
 
 	file, err := ioutil.TempFile("", "file-hook-")
@@ -82,18 +115,18 @@ func fileHook(root string, hook string, initial string) (out string, err error)
 	return
 }
 
-func PreCommitHook(root string) error {
-	return noArgHook(root, "pre-commit")
+func PreCommitHook(r *git.Repository) error {
+	return noArgHook(r, "pre-commit")
 }
 
-func PostCommitHook(root string) error {
-	return noArgHook(root, "post-commit")
+func PostCommitHook(r *git.Repository) error {
+	return noArgHook(r, "post-commit")
 }
 
-func PrepareCommitMsgHook(root string) (msg string, err error) {
-	return fileHook(root, "prepare-commit-msg", "")
+func PrepareCommitMsgHook(r *git.Repository) (msg string, err error) {
+	return fileHook(r, "prepare-commit-msg", "")
 }
 
-func CommitMsgHook(root string, in string) (msg string, err error) {
-	return fileHook(root, "commit-msg", in)
+func CommitMsgHook(r *git.Repository, in string) (msg string, err error) {
+	return fileHook(r, "commit-msg", in)
 }
@@ -6,57 +6,70 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestHookCmd(t *testing.T) {
-	dir, err := ioutil.TempDir("", "turbogit-test-hook")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
-	require.NoError(t, os.Chdir(dir))
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	require.NoError(t, os.Chdir(r.Workdir()))
 
 	// Test when no hooks exists
 	hook := "hook-script"
-	hc, err := hookCmd(dir, hook)
+	hc, err := hookCmd(r, hook)
 	assert.NoError(t, err)
 	assert.Nil(t, hc)
+	script := filepath.Join(r.Path(), "hooks", hook)
 
 	// Test error with directory script instead of file
 	err = os.MkdirAll(path.Join(".git", "hooks", hook), 0700)
 	require.NoError(t, err)
-	hc, err = hookCmd(dir, hook)
-	assert.EqualError(t, err, fmt.Sprintf("Hook .git/hooks/%s is a directory, it should be an executable file.", hook))
+	hc, err = hookCmd(r, hook)
+	assert.EqualError(t, err, fmt.Sprintf("Hook %s is a directory, it should be an executable file.", script))
 	assert.Nil(t, hc)
 	err = os.Remove(path.Join(".git", "hooks", hook))
 	require.NoError(t, err)
 
 	// Test command
 	test.WriteGitHook(t, hook, "")
-	hc, err = hookCmd(dir, hook)
+	hc, err = hookCmd(r, hook)
 	assert.NoError(t, err)
 	assert.Equal(t, &exec.Cmd{
-		Dir:    dir,
-		Path:   path.Join(dir, ".git", "hooks", hook),
-		Args:   []string{path.Join(dir, ".git", "hooks", hook)},
+		Dir:    r.Workdir(),
+		Path:   script,
+		Args:   []string{script},
 		Stdout: os.Stdout,
 		Stderr: os.Stderr,
 	}, hc)
+	// Test hooks path, where tug hooks install
+	c, err := r.Config()
+	require.NoError(t, err)
+	defer c.Free()
+	require.NoError(t, c.SetString("core.hooksPath", ".githooks"))
+	script = filepath.Join(r.Workdir(), ".githooks", hook)
+	require.NoError(t, os.MkdirAll(filepath.Dir(script), 0700))
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\n"), 0777))
+	hc, err = hookCmd(r, hook)
+	assert.NoError(t, err)
+	require.NotNil(t, hc)
+	assert.Equal(t, script, hc.Path)
 }
 
 func TestNoArgHook(t *testing.T) {
-	dir, err := ioutil.TempDir("", "turbogit-test-hook")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
-	require.NoError(t, os.Chdir(dir))
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	require.NoError(t, os.Chdir(r.Workdir()))
 
 	hook := "hook-script"
 
 	// Test without script
-	err = noArgHook(dir, hook)
+	err := noArgHook(r, hook)
 	assert.NoError(t, err)
 
 	// Test error script
@@ -67,7 +80,7 @@ exit 3
 	test.WriteGitHook(t, hook, script)
 	stderr, resetSterr := test.CaptureStd(t, os.Stderr)
 	defer resetSterr()
-	err = noArgHook(dir, hook)
+	err = noArgHook(r, hook)
 	assert.EqualError(t, err, "exit status 3")
 	stde, err := ioutil.ReadFile(stderr.Name())
 	require.NoError(t, err)
@@ -81,7 +94,7 @@ exit 0
 	test.WriteGitHook(t, hook, script)
 	stdout, resetStdout := test.CaptureStd(t, os.Stdout)
 	defer resetStdout()
-	err = noArgHook(dir, hook)
+	err = noArgHook(r, hook)
 	assert.NoError(t, err)
 	stdo, err := ioutil.ReadFile(stdout.Name())
 	require.NoError(t, err)
@@ -89,15 +102,14 @@ exit 0
 }
 
 func TestFileHook(t *testing.T) {
-	dir, err := ioutil.TempDir("", "turbogit-test-hook")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
-	require.NoError(t, os.Chdir(dir))
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	require.NoError(t, os.Chdir(r.Workdir()))
 
 	hook := "hook-script"
 
 	// Test without script
-	msg, err := fileHook(dir, hook, "hello world!")
+	msg, err := fileHook(r, hook, "hello world!")
 	assert.NoError(t, err)
 	assert.Equal(t, "hello world!", msg)
 
@@ -109,7 +121,7 @@ exit 3
 	test.WriteGitHook(t, hook, script)
 	stderr, resetSterr := test.CaptureStd(t, os.Stderr)
 	defer resetSterr()
-	msg, err = fileHook(dir, hook, "hello world!")
+	msg, err = fileHook(r, hook, "hello world!")
 	assert.EqualError(t, err, "exit status 3")
 	assert.Equal(t, "hello world!", msg)
 	stde, err := ioutil.ReadFile(stderr.Name())
@@ -122,16 +134,15 @@ echo "Hello world!" > "$1"
 exit 0
 `
 	test.WriteGitHook(t, hook, script)
-	msg, err = fileHook(dir, hook, "Hey you!")
+	msg, err = fileHook(r, hook, "Hey you!")
 	assert.NoError(t, err)
 	assert.Equal(t, "Hello world!\n", msg)
 }
 
 func TestPreCommitHook(t *testing.T) {
-	dir, err := ioutil.TempDir("", "turbogit-test-hook")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
-	require.NoError(t, os.Chdir(dir))
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	require.NoError(t, os.Chdir(r.Workdir()))
 
 	script := `#!/bin/sh
 echo Hello world!
@@ -140,7 +151,7 @@ exit 0
 	test.WriteGitHook(t, "pre-commit", script)
 	stdout, resetStdout := test.CaptureStd(t, os.Stdout)
 	defer resetStdout()
-	err = PreCommitHook(dir)
+	err := PreCommitHook(r)
 	assert.NoError(t, err)
 	stdo, err := ioutil.ReadFile(stdout.Name())
 	require.NoError(t, err)
@@ -148,10 +159,9 @@ exit 0
 }
 
 func TestPrepareCommitMsg(t *testing.T) {
-	dir, err := ioutil.TempDir("", "turbogit-test-hook")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
-	require.NoError(t, os.Chdir(dir))
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	require.NoError(t, os.Chdir(r.Workdir()))
 
 	// Test successful script
 	script := `#!/bin/sh
@@ -159,16 +169,15 @@ echo "Hello world!" > "$1"
 exit 0
 `
 	test.WriteGitHook(t, "prepare-commit-msg", script)
-	msg, err := PrepareCommitMsgHook(dir)
+	msg, err := PrepareCommitMsgHook(r)
 	assert.NoError(t, err)
 	assert.Equal(t, "Hello world!\n", msg)
 }
 
 func TestCommitMsg(t *testing.T) {
-	dir, err := ioutil.TempDir("", "turbogit-test-hook")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
-	require.NoError(t, os.Chdir(dir))
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	require.NoError(t, os.Chdir(r.Workdir()))
 
 	// Test successful script
 	script := `#!/bin/sh
@@ -176,16 +185,15 @@ echo world! >> "$1"
 exit 0
 `
 	test.WriteGitHook(t, "commit-msg", script)
-	msg, err := CommitMsgHook(dir, "Hello ")
+	msg, err := CommitMsgHook(r, "Hello ")
 	assert.NoError(t, err)
 	assert.Equal(t, "Hello world!\n", msg)
 }
 
 func TestPostCommit(t *testing.T) {
-	dir, err := ioutil.TempDir("", "turbogit-test-hook")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
-	require.NoError(t, os.Chdir(dir))
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	require.NoError(t, os.Chdir(r.Workdir()))
 
 	script := `#!/bin/sh
 echo Hello world!
@@ -194,9 +202,44 @@ exit 0
 	test.WriteGitHook(t, "post-commit", script)
 	stdout, resetStdout := test.CaptureStd(t, os.Stdout)
 	defer resetStdout()
-	err = PostCommitHook(dir)
+	err := PostCommitHook(r)
 	assert.NoError(t, err)
 	stdo, err := ioutil.ReadFile(stdout.Name())
 	require.NoError(t, err)
 	assert.Equal(t, "Running post-commit hook...\nHello world!\n", string(stdo))
 }
+
+func TestHooksDir(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+
+	dir, err := HooksDir(r)
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(r.Path(), "hooks"), path.Clean(dir))
+
+	c, err := r.Config()
+	require.NoError(t, err)
+	require.NoError(t, c.SetString("core.hooksPath", ".githooks"))
+	dir, err = HooksDir(r)
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(r.Workdir(), ".githooks"), dir)
+
+	require.NoError(t, c.SetString("core.hooksPath", "/etc/githooks"))
+	dir, err = HooksDir(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/githooks", dir)
+
+	// Bare repository
+	bdir, err := ioutil.TempDir("", "turbogit-bare")
+	require.NoError(t, err)
+	defer os.RemoveAll(bdir)
+	bare, err := git.InitRepository(bdir, true)
+	require.NoError(t, err)
+	defer bare.Free()
+	bc, err := bare.Config()
+	require.NoError(t, err)
+	require.NoError(t, bc.SetString("core.hooksPath", "githooks"))
+	dir, err = HooksDir(bare)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(bare.Path(), "githooks"), dir)
+}
@@ -0,0 +1,350 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Prefix of the reflog messages of the references tug updates
+	REFLOG_PREFIX = "tug: "
+)
+
+// ReflogMessage returns the reflog message of a tug operation, 'tug: <op>: <detail>'.
+func ReflogMessage(op string, detail string) string {
+	return fmt.Sprintf("%s%s: %s", REFLOG_PREFIX, op, detail)
+}
+
+// ReflogEntry is a reference update recorded in the reflog.
+type ReflogEntry struct {
+	Ref       string
+	Old       *git.Oid
+	New       *git.Oid
+	Committer *git.Signature
+	Message   string
+	// Position in the reflog of the reference, 0 being the newest update
+	Index int
+}
+
+// IsTug returns true if the update was made by tug.
+func (e *ReflogEntry) IsTug() bool {
+	return strings.HasPrefix(e.Message, REFLOG_PREFIX)
+}
+
+// Operation returns the tug operation of the update, or an empty string.
+func (e *ReflogEntry) Operation() string {
+	if !e.IsTug() {
+		return ""
+	}
+	return strings.SplitN(strings.TrimPrefix(e.Message, REFLOG_PREFIX), ":", 2)[0]
+}
+
+// IsCreation returns true if the update created the reference.
+func (e *ReflogEntry) IsCreation() bool {
+	return e.Old.IsZero()
+}
+
+// parseReflogLine parses a '<old> <new> <name> <<email>> <time> <tz>\t<message>' line.
+func parseReflogLine(ref string, line string) (*ReflogEntry, error) {
+	head, msg := line, ""
+	if i := strings.Index(line, "\t"); i >= 0 {
+		head, msg = line[:i], line[i+1:]
+	}
+	fields := strings.SplitN(head, " ", 3)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("invalid reflog entry of %s: %s", ref, line)
+	}
+	old, err := git.NewOid(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	new, err := git.NewOid(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	sig, err := parseReflogIdent(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid reflog entry of %s: %w", ref, err)
+	}
+	return &ReflogEntry{Ref: ref, Old: old, New: new, Committer: sig, Message: msg}, nil
+}
+
+// parseReflogIdent parses a 'name <email> <unix time> <tz>' identity.
+func parseReflogIdent(ident string) (*git.Signature, error) {
+	lt, gt := strings.Index(ident, "<"), strings.LastIndex(ident, ">")
+	if lt < 0 || gt < lt {
+		return nil, fmt.Errorf("invalid identity '%s'", ident)
+	}
+	sig := &git.Signature{Name: strings.TrimSpace(ident[:lt]), Email: ident[lt+1 : gt]}
+	when := strings.Fields(ident[gt+1:])
+	if len(when) != 2 {
+		return nil, fmt.Errorf("invalid identity '%s'", ident)
+	}
+	sec, err := strconv.ParseInt(when[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	tz, err := strconv.Atoi(when[1])
+	if err != nil {
+		return nil, err
+	}
+	offset := (tz/100*60 + tz%100) * 60
+	sig.When = time.Unix(sec, 0).In(time.FixedZone(when[1], offset))
+	return sig, nil
+}
+
+// ReadReflog returns the reflog entries of a reference, newest first.
+// References without reflog have no entries.
+func ReadReflog(r *git.Repository, ref string) ([]*ReflogEntry, error) {
+	f, err := os.Open(filepath.Join(r.Path(), "logs", filepath.FromSlash(ref)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*ReflogEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if sc.Text() == "" {
+			continue
+		}
+		e, err := parseReflogLine(ref, sc.Text())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	// Entries are appended, oldest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	for i, e := range entries {
+		e.Index = i
+	}
+	return entries, sc.Err()
+}
+
+// TugReflog returns the updates tug made to branches and tags, newest first. limit <= 0 means no limit.
+func TugReflog(r *git.Repository, limit int) ([]*ReflogEntry, error) {
+	root := filepath.Join(r.Path(), "logs")
+	var entries []*ReflogEntry
+	for _, dir := range []string{"refs/heads", "refs/tags"} {
+		err := filepath.Walk(filepath.Join(root, filepath.FromSlash(dir)), func(p string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			log, err := ReadReflog(r, filepath.ToSlash(rel))
+			if err != nil {
+				return err
+			}
+			for _, e := range log {
+				if e.IsTug() {
+					entries = append(entries, e)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	seq, err := sequence(r, entries)
+	if err != nil {
+		return nil, err
+	}
+	// Reflog times have a second resolution, updates of the same second are ordered by sequence,
+	// then by reference and reflog order
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if ta, tb := a.Committer.When, b.Committer.When; !ta.Equal(tb) {
+			return ta.After(tb)
+		}
+		if seq[a] != seq[b] {
+			return seq[a] < seq[b]
+		}
+		if a.Ref != b.Ref {
+			return a.Ref < b.Ref
+		}
+		return a.Index < b.Index
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// key identifies an update across the reflogs it is recorded in.
+func (e *ReflogEntry) key() string {
+	return fmt.Sprintf("%s %s %d %s", e.Old, e.New, e.Committer.When.Unix(), e.Message)
+}
+
+// sequence ranks the updates across references, the lower the newer. Updates made through HEAD are ranked by
+// their position in the HEAD reflog. A reference created on the result of such an update (tag of a release,
+// new branch) is ranked right before it. Other updates are ranked last.
+func sequence(r *git.Repository, entries []*ReflogEntry) (map[*ReflogEntry]int, error) {
+	log, err := ReadReflog(r, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	head := make(map[string]int, len(log))
+	for _, e := range log {
+		if _, ok := head[e.key()]; !ok {
+			head[e.key()] = e.Index
+		}
+	}
+
+	unknown := 2*len(log) + 1
+	seq := make(map[*ReflogEntry]int, len(entries))
+	byResult := map[string]int{}
+	for _, e := range entries {
+		seq[e] = unknown
+		if i, ok := head[e.key()]; ok {
+			seq[e] = 2*i + 1
+			byResult[fmt.Sprintf("%s %d", e.New, e.Committer.When.Unix())] = seq[e]
+		}
+	}
+	for _, e := range entries {
+		if seq[e] != unknown || !e.IsCreation() {
+			continue
+		}
+		if s, ok := byResult[fmt.Sprintf("%s %d", e.New, e.Committer.When.Unix())]; ok {
+			seq[e] = s - 1
+		}
+	}
+	return seq, nil
+}
+
+// UpdateHead moves HEAD, or the branch it points to, to id. The reflog records msg.
+func UpdateHead(r *git.Repository, id *git.Oid, msg string) error {
+	head, err := r.Head()
+	if err != nil {
+		// Unborn branch
+		ref, err := r.References.Lookup("HEAD")
+		if err != nil {
+			return err
+		}
+		defer ref.Free()
+		created, err := r.References.Create(ref.SymbolicTarget(), id, false, msg)
+		if err != nil {
+			return err
+		}
+		created.Free()
+		return nil
+	}
+	defer head.Free()
+	// SetTarget fails if the reference moved in between
+	updated, err := head.SetTarget(id, msg)
+	if err != nil {
+		return err
+	}
+	updated.Free()
+	return nil
+}
+
+// CreateTag creates a lightweight tag on id, logging its creation so that it can be undone.
+func CreateTag(r *git.Repository, name string, id *git.Oid, msg string) (*git.Reference, error) {
+	refname := "refs/tags/" + name
+	if err := r.References.EnsureLog(refname); err != nil {
+		return nil, err
+	}
+	return r.References.Create(refname, id, false, msg)
+}
+
+// CreateBranch creates a branch on id, with msg in its reflog.
+func CreateBranch(r *git.Repository, name string, id *git.Oid, msg string) (*git.Branch, error) {
+	ref, err := r.References.Create("refs/heads/"+name, id, false, msg)
+	if err != nil {
+		return nil, err
+	}
+	return ref.Branch(), nil
+}
+
+// RenameBranch renames the branch ref to name, with msg in its reflog.
+// Unlike a plain reference rename, the branch.<name>.* configuration (upstream, description...) follows.
+func RenameBranch(r *git.Repository, ref *git.Reference, name string, msg string) (*git.Reference, error) {
+	old := strings.TrimPrefix(ref.Name(), "refs/heads/")
+	renamed, err := ref.Rename("refs/heads/"+name, false, msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := moveBranchConfig(r, old, name); err != nil {
+		renamed.Free()
+		return nil, err
+	}
+	return renamed, nil
+}
+
+// moveBranchConfig moves the branch.<old>.* configuration entries to branch.<name>.*.
+func moveBranchConfig(r *git.Repository, old string, name string) error {
+	c, err := r.Config()
+	if err != nil {
+		return err
+	}
+	defer c.Free()
+	prefix := "branch." + old + "."
+	it, err := c.NewIteratorGlob(`^` + regexp.QuoteMeta(prefix))
+	if err != nil {
+		return err
+	}
+	var entries []*git.ConfigEntry
+	for {
+		entry, err := it.Next()
+		if git.IsErrorCode(err, git.ErrorCodeIterOver) {
+			break
+		}
+		if err != nil {
+			it.Free()
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	it.Free()
+
+	values := map[string][]string{}
+	var names []string
+	for _, e := range entries {
+		if _, ok := values[e.Name]; !ok {
+			names = append(names, e.Name)
+		}
+		values[e.Name] = append(values[e.Name], e.Value)
+	}
+	for _, n := range names {
+		key := "branch." + name + "." + strings.TrimPrefix(n, prefix)
+		if len(values[n]) > 1 {
+			// Multivars cannot be deleted through libgit2 bindings, they are only copied
+			for _, v := range values[n] {
+				if err := c.SetMultivar(key, "^$", v); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := c.SetString(key, values[n][0]); err != nil {
+			return err
+		}
+		// Entries of the global configuration are not deleted
+		if err := c.Delete(n); err != nil && !git.IsErrorCode(err, git.ErrorCodeNotFound) {
+			return err
+		}
+	}
+	return nil
+}
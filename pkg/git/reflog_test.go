@@ -0,0 +1,165 @@
+package git
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReflogLine(t *testing.T) {
+	line := "0000000000000000000000000000000000000000 1111111111111111111111111111111111111111 Alice Doe <alice@example.com> 1600000000 -0130\ttug: new: created from main"
+	e, err := parseReflogLine("refs/heads/feat/foo", line)
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/feat/foo", e.Ref)
+	assert.True(t, e.IsCreation())
+	assert.True(t, e.IsTug())
+	assert.Equal(t, "new", e.Operation())
+	assert.Equal(t, "Alice Doe", e.Committer.Name)
+	assert.Equal(t, "alice@example.com", e.Committer.Email)
+	assert.Equal(t, int64(1600000000), e.Committer.When.Unix())
+	_, offset := e.Committer.When.Zone()
+	assert.Equal(t, -90*60, offset)
+
+	_, err = parseReflogLine("HEAD", "foo bar")
+	assert.Error(t, err)
+}
+
+func TestTugReflog(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	first, err := Commit(r, "feat: first")
+	require.NoError(t, err)
+	second, err := Commit(r, "fix: second")
+	require.NoError(t, err)
+	amended, err := Amend(second, "fix: amended")
+	require.NoError(t, err)
+	_, err = CreateTag(r, "v1.0.0", amended.Id(), ReflogMessage("release", "v1.0.0"))
+	require.NoError(t, err)
+
+	head, err := r.Head()
+	require.NoError(t, err)
+	log, err := ReadReflog(r, head.Name())
+	require.NoError(t, err)
+	require.Len(t, log, 3)
+	assert.Equal(t, "tug: commit (amend): fix: amended", log[0].Message)
+	assert.Equal(t, second.Id(), log[0].Old)
+	assert.Equal(t, amended.Id(), log[0].New)
+	assert.Equal(t, "tug: commit: fix: second", log[1].Message)
+	assert.Equal(t, "tug: commit (initial): feat: first", log[2].Message)
+	assert.Equal(t, first.Id(), log[2].New)
+	assert.WithinDuration(t, time.Now(), log[0].Committer.When, 5*time.Second)
+
+	entries, err := TugReflog(r, 0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4)
+	var tag *ReflogEntry
+	for _, e := range entries {
+		if e.Ref == "refs/tags/v1.0.0" {
+			tag = e
+		}
+	}
+	require.NotNil(t, tag)
+	assert.True(t, tag.IsCreation())
+	assert.Equal(t, "release", tag.Operation())
+
+	entries, err = TugReflog(r, 2)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestTugReflogSameSecond(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+
+	// Commits of a split, all in the same second, and an older update of another branch
+	ids := []string{strings.Repeat("0", 40), strings.Repeat("1", 40), strings.Repeat("2", 40), strings.Repeat("3", 40)}
+	var lines []string
+	for i := 1; i < len(ids); i++ {
+		lines = append(lines, fmt.Sprintf("%s %s Alice <alice@example.com> 1600000000 +0000\ttug: commit: split %d", ids[i-1], ids[i], i))
+	}
+	logs := filepath.Join(r.Path(), "logs", "refs", "heads")
+	require.NoError(t, os.MkdirAll(logs, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(logs, "main"), []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	other := fmt.Sprintf("%s %s Alice <alice@example.com> 1599999999 +0000\ttug: commit: other\n", ids[0], ids[1])
+	require.NoError(t, ioutil.WriteFile(filepath.Join(logs, "aaa"), []byte(other), 0644))
+
+	entries, err := TugReflog(r, 0)
+	require.NoError(t, err)
+	var msgs []string
+	for _, e := range entries {
+		msgs = append(msgs, e.Message)
+	}
+	assert.Equal(t, []string{"tug: commit: split 3", "tug: commit: split 2", "tug: commit: split 1", "tug: commit: other"}, msgs)
+	assert.Equal(t, 0, entries[0].Index)
+}
+
+func TestTugReflogSameSecondRefs(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+
+	zero, one, two, three := strings.Repeat("0", 40), strings.Repeat("1", 40), strings.Repeat("2", 40), strings.Repeat("3", 40)
+	line := func(old, new, msg string) string {
+		return fmt.Sprintf("%s %s Alice <alice@example.com> 1600000000 +0000\t%s\n", old, new, msg)
+	}
+	write := func(ref string, content string) {
+		p := filepath.Join(r.Path(), "logs", filepath.FromSlash(ref))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+	}
+	// A commit on b, then on a, through HEAD. A release tags the commit on a.
+	write("refs/heads/a", line(one, two, "tug: commit: on a"))
+	write("refs/heads/b", line(one, three, "tug: commit: on b"))
+	write("HEAD", line(one, three, "tug: commit: on b")+line(three, one, "checkout: moving from b to a")+line(one, two, "tug: commit: on a"))
+	write("refs/tags/v1.0.0", line(zero, two, "tug: release: v1.0.0"))
+
+	entries, err := TugReflog(r, 0)
+	require.NoError(t, err)
+	var msgs []string
+	for _, e := range entries {
+		msgs = append(msgs, e.Message)
+	}
+	assert.Equal(t, []string{"tug: release: v1.0.0", "tug: commit: on a", "tug: commit: on b"}, msgs)
+}
+
+func TestRenameBranch(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	c, err := Commit(r, "feat: base")
+	require.NoError(t, err)
+	b, err := CreateBranch(r, "foo", c.Id(), ReflogMessage("new", "created"))
+	require.NoError(t, err)
+	conf, err := r.Config()
+	require.NoError(t, err)
+	defer conf.Free()
+	require.NoError(t, conf.SetString("branch.foo.remote", "origin"))
+	require.NoError(t, conf.SetString("branch.foo.merge", "refs/heads/feat/foo"))
+
+	renamed, err := RenameBranch(r, b.Reference, "feat/foo", ReflogMessage("check", "renamed foo to feat/foo"))
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/feat/foo", renamed.Name())
+
+	remote, err := conf.LookupString("branch.feat/foo.remote")
+	require.NoError(t, err)
+	assert.Equal(t, "origin", remote)
+	merge, err := conf.LookupString("branch.feat/foo.merge")
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/feat/foo", merge)
+	_, err = conf.LookupString("branch.foo.remote")
+	assert.Error(t, err)
+
+	log, err := ReadReflog(r, "refs/heads/feat/foo")
+	require.NoError(t, err)
+	require.NotEmpty(t, log)
+	assert.Equal(t, "tug: check: renamed foo to feat/foo", log[0].Message)
+}
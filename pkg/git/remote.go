@@ -1,6 +1,7 @@
 package git
 
 import (
+	"fmt"
 	"net/url"
 
 	git "github.com/libgit2/git2go/v33"
@@ -18,6 +19,9 @@ func ParseRemote(r *git.Repository, name string, fallback bool) (*url.URL, error
 		if err != nil {
 			return nil, err
 		}
+		if len(rl) == 0 {
+			return nil, fmt.Errorf("remote '%s' does not exist and there is no other remote", name)
+		}
 		rawurl = rl[0]
 	} else {
 		rawurl = remote.Url()
@@ -11,7 +11,12 @@ import (
 func TestParseRemote(t *testing.T) {
 	r := test.TestRepo(t)
 	defer test.CleanupRepo(t, r)
-	_, err := r.Remotes.Create("origin", "git@alice.com:namespace/project.git")
+
+	// No remote
+	_, err := ParseRemote(r, "origin", true)
+	assert.EqualError(t, err, "remote 'origin' does not exist and there is no other remote")
+
+	_, err = r.Remotes.Create("origin", "git@alice.com:namespace/project.git")
 	require.NoError(t, err)
 
 	// Direct
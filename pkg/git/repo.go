@@ -24,6 +24,12 @@ func Getrepo() (*git2go.Repository, error) {
 	return repo, nil
 }
 
+// OpenFromEnv reopens the repository at path honoring the git environment (GIT_OBJECT_DIRECTORY,
+// GIT_ALTERNATE_OBJECT_DIRECTORIES...), so that objects quarantined by a pre-receive hook can be read.
+func OpenFromEnv(path string) (*git2go.Repository, error) {
+	return git2go.OpenRepositoryExtended(path, git2go.RepositoryOpenFromEnv, "")
+}
+
 func StagedDiff(r *git2go.Repository) (*git2go.Diff, error) {
 	var tree *git2go.Tree
 	if obj, err := r.RevparseSingle("HEAD^{tree}"); err == nil {
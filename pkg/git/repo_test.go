@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/b4nst/turbogit/pkg/test"
@@ -33,6 +34,40 @@ func TestGetrepo(t *testing.T) {
 	assert.Equal(t, r, repo)
 }
 
+func TestOpenFromEnv(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	_, err := Commit(r, "feat: base")
+	require.NoError(t, err)
+
+	// Objects received in a quarantine directory, as during pre-receive
+	q := test.TestRepo(t)
+	defer test.CleanupRepo(t, q)
+	test.InitRepoConf(t, q)
+	pushed, err := Commit(q, "feat: pushed")
+	require.NoError(t, err)
+	_, err = r.LookupCommit(pushed.Id())
+	assert.Error(t, err)
+
+	defer os.Setenv("GIT_OBJECT_DIRECTORY", os.Getenv("GIT_OBJECT_DIRECTORY"))
+	defer os.Setenv("GIT_ALTERNATE_OBJECT_DIRECTORIES", os.Getenv("GIT_ALTERNATE_OBJECT_DIRECTORIES"))
+	require.NoError(t, os.Setenv("GIT_OBJECT_DIRECTORY", filepath.Join(q.Path(), "objects")))
+	require.NoError(t, os.Setenv("GIT_ALTERNATE_OBJECT_DIRECTORIES", filepath.Join(r.Path(), "objects")))
+
+	repo, err := OpenFromEnv(r.Path())
+	require.NoError(t, err)
+	defer repo.Free()
+	c, err := repo.LookupCommit(pushed.Id())
+	require.NoError(t, err)
+	assert.Equal(t, "feat: pushed", c.Message())
+	head, err := repo.Head()
+	require.NoError(t, err)
+	defer head.Free()
+	_, err = repo.LookupCommit(head.Target())
+	assert.NoError(t, err)
+}
+
 func TestStagedDiff(t *testing.T) {
 	r := test.TestRepo(t)
 	defer test.CleanupRepo(t, r)
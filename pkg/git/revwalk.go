@@ -0,0 +1,95 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	git "github.com/libgit2/git2go/v33"
+)
+
+// ResolveCommit returns the id of the commit a revision points to, peeling tags if needed.
+func ResolveCommit(r *git.Repository, rev string) (*git.Oid, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	obj, err := r.RevparseSingle(rev)
+	if err != nil {
+		return nil, err
+	}
+	c, err := obj.Peel(git.ObjectCommit)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a commit: %w", rev, err)
+	}
+	return c.Id(), nil
+}
+
+// PushRevisions configures a revision walker from git-style revisions.
+// Supported forms are 'A' (commits reachable from A), '^A' (hide commits reachable from A),
+// 'A..B' (reachable from B but not from A) and 'A...B' (reachable from either A or B but not from both).
+// An omitted side of a range defaults to HEAD.
+func PushRevisions(r *git.Repository, walk *git.RevWalk, revs []string) error {
+	for _, rev := range revs {
+		if err := pushRevision(r, walk, rev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pushRevision(r *git.Repository, walk *git.RevWalk, rev string) error {
+	switch {
+	case strings.HasPrefix(rev, "^"):
+		id, err := ResolveCommit(r, rev[1:])
+		if err != nil {
+			return err
+		}
+		return walk.Hide(id)
+	case strings.Contains(rev, "..."):
+		split := strings.SplitN(rev, "...", 2)
+		a, err := ResolveCommit(r, split[0])
+		if err != nil {
+			return err
+		}
+		b, err := ResolveCommit(r, split[1])
+		if err != nil {
+			return err
+		}
+		if err := walk.Push(a); err != nil {
+			return err
+		}
+		if err := walk.Push(b); err != nil {
+			return err
+		}
+		bases, err := r.MergeBases(a, b)
+		if err != nil {
+			// Unrelated histories, nothing to hide
+			return nil
+		}
+		for _, base := range bases {
+			if err := walk.Hide(base); err != nil {
+				return err
+			}
+		}
+		return nil
+	case strings.Contains(rev, ".."):
+		split := strings.SplitN(rev, "..", 2)
+		a, err := ResolveCommit(r, split[0])
+		if err != nil {
+			return err
+		}
+		b, err := ResolveCommit(r, split[1])
+		if err != nil {
+			return err
+		}
+		if err := walk.Hide(a); err != nil {
+			return err
+		}
+		return walk.Push(b)
+	default:
+		id, err := ResolveCommit(r, rev)
+		if err != nil {
+			return err
+		}
+		return walk.Push(id)
+	}
+}
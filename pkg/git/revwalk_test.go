@@ -0,0 +1,70 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushRevisions(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	// c1 - c2 - c5 (HEAD)
+	//        \
+	//         c3 - c4 (feature)
+	_, err := Commit(r, "c1")
+	require.NoError(t, err)
+	c2, err := Commit(r, "c2")
+	require.NoError(t, err)
+	_, err = r.CreateBranch("feature", c2, false)
+	require.NoError(t, err)
+	_, err = Commit(r, "c5")
+	require.NoError(t, err)
+	sig, err := r.DefaultSignature()
+	require.NoError(t, err)
+	tree, err := c2.Tree()
+	require.NoError(t, err)
+	c3id, err := r.CreateCommit("refs/heads/feature", sig, sig, "c3", tree, c2)
+	require.NoError(t, err)
+	c3, err := r.LookupCommit(c3id)
+	require.NoError(t, err)
+	_, err = r.CreateCommit("refs/heads/feature", sig, sig, "c4", tree, c3)
+	require.NoError(t, err)
+
+	tcs := map[string]struct {
+		revs     []string
+		expected []string
+	}{
+		"Single":               {[]string{"HEAD"}, []string{"c5", "c2", "c1"}},
+		"Range":                {[]string{"HEAD..feature"}, []string{"c4", "c3"}},
+		"Range default HEAD":   {[]string{"feature.."}, []string{"c5"}},
+		"Symmetric difference": {[]string{"HEAD...feature"}, []string{"c5", "c4", "c3"}},
+		"Hide":                 {[]string{"feature", "^HEAD"}, []string{"c4", "c3"}},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			walk, err := r.Walk()
+			require.NoError(t, err)
+			defer walk.Free()
+
+			assert.NoError(t, PushRevisions(r, walk, tc.revs))
+			var msgs []string
+			require.NoError(t, walk.Iterate(func(c *git.Commit) bool {
+				msgs = append(msgs, c.Message())
+				return true
+			}))
+			assert.ElementsMatch(t, tc.expected, msgs)
+		})
+	}
+
+	walk, err := r.Walk()
+	require.NoError(t, err)
+	defer walk.Free()
+	assert.Error(t, PushRevisions(r, walk, []string{"HEAD..unknown"}))
+}
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	git "github.com/libgit2/git2go/v33"
+)
+
+// Rewriter returns the new message of a commit. Returning the current message keeps it.
+type Rewriter func(c *git.Commit) (string, error)
+
+// RewriteHistory recreates the commits reachable from tip and not from base (if not nil), oldest first,
+// with the messages returned by rewrite. Trees, authors, committers and dates are preserved.
+// Commits whose message and parents are unchanged are kept as is.
+// It returns the new tip and the mapping of every walked commit ID to its new ID. No reference is updated.
+func RewriteHistory(r *git.Repository, tip *git.Oid, base *git.Oid, rewrite Rewriter) (*git.Oid, map[git.Oid]*git.Oid, error) {
+	walk, err := r.Walk()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer walk.Free()
+	walk.Sorting(git.SortTopological | git.SortReverse)
+	if err := walk.Push(tip); err != nil {
+		return nil, nil, err
+	}
+	if base != nil {
+		if err := walk.Hide(base); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	mapping := map[git.Oid]*git.Oid{}
+	var werr error
+	err = walk.Iterate(func(c *git.Commit) bool {
+		var id *git.Oid
+		if id, werr = rewriteCommit(r, c, mapping, rewrite); werr != nil {
+			return false
+		}
+		mapping[*c.Id()] = id
+		return true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if werr != nil {
+		return nil, nil, werr
+	}
+
+	newTip, ok := mapping[*tip]
+	if !ok {
+		// Nothing to rewrite
+		newTip = tip
+	}
+	return newTip, mapping, nil
+}
+
+func rewriteCommit(r *git.Repository, c *git.Commit, mapping map[git.Oid]*git.Oid, rewrite Rewriter) (*git.Oid, error) {
+	msg, err := rewrite(c)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := msg != c.Message()
+	parents := make([]*git.Commit, 0, c.ParentCount())
+	for i := uint(0); i < c.ParentCount(); i++ {
+		pid := c.ParentId(i)
+		if npid, ok := mapping[*pid]; ok && !npid.Equal(pid) {
+			pid = npid
+			changed = true
+		}
+		parent, err := r.LookupCommit(pid)
+		if err != nil {
+			return nil, err
+		}
+		defer parent.Free()
+		parents = append(parents, parent)
+	}
+	if !changed {
+		return c.Id(), nil
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+	return r.CreateCommit("", c.Author(), c.Committer(), msg, tree, parents...)
+}
+
+// Reword changes the message of target, an ancestor of HEAD, and rebuilds its descendants on top of it.
+// The current branch is moved only if it still points to the original HEAD.
+// It returns the ID of the reworded commit.
+func Reword(r *git.Repository, target *git.Commit, msg string) (*git.Oid, error) {
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+	if !head.IsBranch() {
+		return nil, errors.New("HEAD is detached, checkout a branch first")
+	}
+	if !head.Target().Equal(target.Id()) {
+		descendant, err := r.DescendantOf(head.Target(), target.Id())
+		if err != nil {
+			return nil, err
+		}
+		if !descendant {
+			return nil, fmt.Errorf("%s is not an ancestor of HEAD", target.Id())
+		}
+	}
+
+	var base *git.Oid
+	if target.ParentCount() > 0 {
+		base = target.ParentId(0)
+	}
+	tip, mapping, err := RewriteHistory(r, head.Target(), base, func(c *git.Commit) (string, error) {
+		if c.Id().Equal(target.Id()) {
+			return msg, nil
+		}
+		return c.Message(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// SetTarget fails if the branch moved in between
+	ref, err := head.SetTarget(tip, ReflogMessage("reword", summary(msg)))
+	if err != nil {
+		return nil, err
+	}
+	ref.Free()
+	return mapping[*target.Id()], nil
+}
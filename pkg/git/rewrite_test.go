@@ -0,0 +1,96 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteHistory(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	c1, err := Commit(r, "feat: first")
+	require.NoError(t, err)
+	c2, err := Commit(r, "fixed stuff")
+	require.NoError(t, err)
+	c3, err := Commit(r, "feat: third")
+	require.NoError(t, err)
+
+	tip, mapping, err := RewriteHistory(r, c3.Id(), nil, func(c *git.Commit) (string, error) {
+		if strings.HasPrefix(c.Message(), "fixed") {
+			return "fix: stuff", nil
+		}
+		return c.Message(), nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, mapping, 3)
+	// Unchanged ancestors are kept
+	assert.Equal(t, c1.Id(), mapping[*c1.Id()])
+	assert.NotEqual(t, c2.Id(), mapping[*c2.Id()])
+	assert.Equal(t, mapping[*c3.Id()], tip)
+
+	nc3, err := r.LookupCommit(tip)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: third", nc3.Message())
+	assert.Equal(t, c3.TreeId(), nc3.TreeId())
+	assert.Equal(t, c3.Author().When.Unix(), nc3.Author().When.Unix())
+	assert.Equal(t, c3.Committer().When.Unix(), nc3.Committer().When.Unix())
+	nc2 := nc3.Parent(0)
+	assert.Equal(t, "fix: stuff", nc2.Message())
+	assert.Equal(t, c1.Id(), nc2.ParentId(0))
+
+	// HEAD is not moved
+	head, err := r.Head()
+	require.NoError(t, err)
+	assert.Equal(t, c3.Id(), head.Target())
+
+	// Base hides older commits
+	_, mapping, err = RewriteHistory(r, c3.Id(), c2.Id(), func(c *git.Commit) (string, error) {
+		return c.Message(), nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, mapping, 1)
+}
+
+func TestReword(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	c1, err := Commit(r, "feat: first")
+	require.NoError(t, err)
+	c2, err := Commit(r, "fixed stuff")
+	require.NoError(t, err)
+	c3, err := Commit(r, "feat: third")
+	require.NoError(t, err)
+
+	id, err := Reword(r, c2, "fix: stuff")
+	require.NoError(t, err)
+	nc2, err := r.LookupCommit(id)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: stuff", nc2.Message())
+	assert.Equal(t, c1.Id(), nc2.ParentId(0))
+
+	head, err := r.Head()
+	require.NoError(t, err)
+	nc3, err := r.LookupCommit(head.Target())
+	require.NoError(t, err)
+	assert.Equal(t, "feat: third", nc3.Message())
+	assert.Equal(t, c3.TreeId(), nc3.TreeId())
+	assert.Equal(t, id, nc3.ParentId(0))
+
+	// Not an ancestor anymore
+	_, err = Reword(r, c3, "feat: nope")
+	assert.EqualError(t, err, c3.Id().String()+" is not an ancestor of HEAD")
+
+	// Detached HEAD
+	require.NoError(t, r.SetHeadDetached(nc3.Id()))
+	_, err = Reword(r, nc3, "feat: nope")
+	assert.EqualError(t, err, "HEAD is detached, checkout a branch first")
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Multivar listing the co-authors, as 'name <email>'
+	ROSTER_CONFIG = "tug.coauthor"
+)
+
+// Roster returns the co-authors one may pair with, as 'name <email>', without the current user.
+// It defaults to the authors of the HEAD history, the most frequent first, when tug.coauthor is not set.
+func Roster(r *git.Repository) ([]string, error) {
+	roster, err := configRoster(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(roster) == 0 {
+		if roster, err = historyRoster(r); err != nil {
+			return nil, err
+		}
+	}
+
+	me := ""
+	if sig, err := r.DefaultSignature(); err == nil {
+		me = strings.ToLower(sig.Email)
+	}
+	filtered := roster[:0]
+	for _, ident := range roster {
+		if _, email := format.ParseIdent(ident); me == "" || strings.ToLower(email) != me {
+			filtered = append(filtered, ident)
+		}
+	}
+	return filtered, nil
+}
+
+// configRoster returns the co-authors listed in the configuration.
+func configRoster(r *git.Repository) ([]string, error) {
+	c, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Free()
+	it, err := c.NewMultivarIterator(ROSTER_CONFIG, "")
+	if err != nil {
+		if git.IsErrorCode(err, git.ErrorCodeNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer it.Free()
+
+	var roster []string
+	for {
+		entry, err := it.Next()
+		if git.IsErrorCode(err, git.ErrorCodeIterOver) || git.IsErrorCode(err, git.ErrorCodeNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if name, email := format.ParseIdent(entry.Value); name == "" || email == "" {
+			return nil, fmt.Errorf("invalid %s '%s', expected 'name <email>'", ROSTER_CONFIG, entry.Value)
+		}
+		roster = append(roster, strings.TrimSpace(entry.Value))
+	}
+	return roster, nil
+}
+
+// historyRoster returns the authors of the HEAD history, the most frequent first.
+func historyRoster(r *git.Repository) ([]string, error) {
+	if unborn, err := r.IsHeadUnborn(); err != nil || unborn {
+		return nil, err
+	}
+	walk, err := r.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer walk.Free()
+	if err := walk.PushHead(); err != nil {
+		return nil, err
+	}
+
+	type author struct {
+		ident   string
+		commits int
+	}
+	byEmail := map[string]*author{}
+	var authors []*author
+	err = walk.Iterate(func(c *git.Commit) bool {
+		sig := c.Author()
+		key := strings.ToLower(sig.Email)
+		a, ok := byEmail[key]
+		if !ok {
+			// Walked from the newest commit, the latest name is kept
+			a = &author{ident: fmt.Sprintf("%s <%s>", sig.Name, sig.Email)}
+			byEmail[key] = a
+			authors = append(authors, a)
+		}
+		a.commits++
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(authors, func(i, j int) bool {
+		return authors[i].commits > authors[j].commits
+	})
+	roster := make([]string, 0, len(authors))
+	for _, a := range authors {
+		roster = append(roster, a.ident)
+	}
+	return roster, nil
+}
@@ -0,0 +1,42 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoster(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	roster, err := Roster(r)
+	require.NoError(t, err)
+	assert.Empty(t, roster)
+
+	c, err := Commit(r, "feat: base")
+	require.NoError(t, err)
+	for _, author := range []string{"Carol", "Bob", "Bob"} {
+		c = danglingCommit(t, r, c, author, "feat: "+author)
+		require.NoError(t, UpdateHead(r, c.Id(), "test"))
+	}
+	roster, err = Roster(r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bob <Bob@example.com>", "Carol <Carol@example.com>"}, roster)
+
+	conf, err := r.Config()
+	require.NoError(t, err)
+	defer conf.Free()
+	require.NoError(t, conf.SetMultivar(ROSTER_CONFIG, "^$", "Dave <dave@example.com>"))
+	require.NoError(t, conf.SetMultivar(ROSTER_CONFIG, "^$", test.GIT_USERNAME+" <"+test.GIT_EMAIL+">"))
+	roster, err = Roster(r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dave <dave@example.com>"}, roster)
+
+	require.NoError(t, conf.SetMultivar(ROSTER_CONFIG, "^$", "nobody"))
+	_, err = Roster(r)
+	assert.EqualError(t, err, "invalid tug.coauthor 'nobody', expected 'name <email>'")
+}
@@ -31,3 +31,96 @@ func StageReady(r *git.Repository) (bool, error) {
 	}
 	return false, errors.New("No changes added to commit")
 }
+
+// StageOnly resets the index to the HEAD tree, then stages the given paths as they are in tree.
+// Paths missing from tree are staged for deletion.
+func StageOnly(r *git.Repository, tree *git.Tree, paths []string) error {
+	idx, err := r.Index()
+	if err != nil {
+		return err
+	}
+	stats, err := indexStats(idx)
+	if err != nil {
+		return err
+	}
+	if obj, err := r.RevparseSingle("HEAD^{tree}"); err == nil {
+		head, err := obj.AsTree()
+		if err != nil {
+			return err
+		}
+		if err := idx.ReadTree(head); err != nil {
+			return err
+		}
+	} else if err := idx.Clear(); err != nil { // Unborn HEAD
+		return err
+	}
+
+	for _, p := range paths {
+		te, err := tree.EntryByPath(p)
+		if err != nil {
+			if err := idx.RemoveByPath(p); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := idx.Add(&git.IndexEntry{Mode: te.Filemode, Id: te.Id, Path: p}); err != nil {
+			return err
+		}
+	}
+	if err := restoreStats(idx, stats); err != nil {
+		return err
+	}
+	return idx.Write()
+}
+
+// ResetIndex replaces the index content by tree.
+func ResetIndex(r *git.Repository, tree *git.Tree) error {
+	idx, err := r.Index()
+	if err != nil {
+		return err
+	}
+	stats, err := indexStats(idx)
+	if err != nil {
+		return err
+	}
+	if err := idx.ReadTree(tree); err != nil {
+		return err
+	}
+	if err := restoreStats(idx, stats); err != nil {
+		return err
+	}
+	return idx.Write()
+}
+
+// indexStats returns the index entries by path, to restore their stat information once the index is rebuilt.
+func indexStats(idx *git.Index) (map[string]*git.IndexEntry, error) {
+	stats := make(map[string]*git.IndexEntry, idx.EntryCount())
+	for i := uint(0); i < idx.EntryCount(); i++ {
+		e, err := idx.EntryByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		stats[e.Path] = e
+	}
+	return stats, nil
+}
+
+// restoreStats copies the stat information of the unchanged entries, which reading a tree or adding
+// an entry by ID drops, so that the files do not show as modified until the index is refreshed.
+func restoreStats(idx *git.Index, stats map[string]*git.IndexEntry) error {
+	for i := uint(0); i < idx.EntryCount(); i++ {
+		e, err := idx.EntryByIndex(i)
+		if err != nil {
+			return err
+		}
+		old, ok := stats[e.Path]
+		if !ok || !old.Id.Equal(e.Id) || old.Mode != e.Mode {
+			continue
+		}
+		e.Ctime, e.Mtime, e.Uid, e.Gid, e.Size = old.Ctime, old.Mtime, old.Uid, old.Gid, old.Size
+		if err := idx.Add(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
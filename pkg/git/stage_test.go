@@ -1,10 +1,13 @@
 package git
 
 import (
+	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/b4nst/turbogit/pkg/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStageReady(t *testing.T) {
@@ -24,3 +27,45 @@ func TestStageReady(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, nc)
 }
+
+func TestStageOnly(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	f1, f2 := test.NewFile(t, r), test.NewFile(t, r)
+	fmt.Fprintln(f1, "content")
+	test.StageFile(t, f1, r)
+	test.StageFile(t, f2, r)
+	staged, err := RepoTree(r)
+	require.NoError(t, err)
+	p1, err := filepath.Rel(r.Workdir(), f1.Name())
+	require.NoError(t, err)
+
+	idx, err := r.Index()
+	require.NoError(t, err)
+	before, err := idx.EntryByPath(p1, 0)
+	require.NoError(t, err)
+	require.NotZero(t, before.Size)
+
+	require.NoError(t, StageOnly(r, staged, []string{p1}))
+	// Stat information is kept
+	after, err := idx.EntryByPath(p1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, before.Mtime, after.Mtime)
+	assert.Equal(t, before.Size, after.Size)
+	diff, err := StagedDiff(r)
+	require.NoError(t, err)
+	files, err := DiffFiles(diff)
+	require.NoError(t, err)
+	assert.Equal(t, []string{p1}, files)
+
+	_, err = Commit(r, "feat: first")
+	require.NoError(t, err)
+	require.NoError(t, ResetIndex(r, staged))
+	diff, err = StagedDiff(r)
+	require.NoError(t, err)
+	n, err := diff.NumDeltas()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	MERGE_HEAD       = "MERGE_HEAD"
+	MERGE_MSG        = "MERGE_MSG"
+	CHERRY_PICK_HEAD = "CHERRY_PICK_HEAD"
+	REVERT_HEAD      = "REVERT_HEAD"
+)
+
+// readStateHeads returns the IDs listed in a state file of the git directory, or nil if it does not exist.
+func readStateHeads(r *git.Repository, name string) ([]*git.Oid, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(r.Path(), name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []*git.Oid
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" {
+			continue
+		}
+		id, err := git.NewOid(l)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, sc.Err()
+}
+
+// stateCommit returns the commit of a single head state file (CHERRY_PICK_HEAD, REVERT_HEAD), or nil if it does not exist.
+func stateCommit(r *git.Repository, name string) (*git.Commit, error) {
+	ids, err := readStateHeads(r, name)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return r.LookupCommit(ids[0])
+}
+
+// MergeHeads returns the commits being merged into HEAD, if a merge is in progress.
+func MergeHeads(r *git.Repository) ([]*git.Commit, error) {
+	if r.State() != git.RepositoryStateMerge {
+		return nil, nil
+	}
+	ids, err := readStateHeads(r, MERGE_HEAD)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]*git.Commit, 0, len(ids))
+	for _, id := range ids {
+		c, err := r.LookupCommit(id)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// StateMessage returns a conventional message for the merge, cherry-pick or revert in progress,
+// or an empty string if there is none.
+func StateMessage(r *git.Repository) (string, error) {
+	switch r.State() {
+	case git.RepositoryStateMerge:
+		raw, err := ioutil.ReadFile(filepath.Join(r.Path(), MERGE_MSG))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		header := strings.TrimSpace(strings.SplitN(string(raw), "\n", 2)[0])
+		if header == "" || strings.HasPrefix(header, "#") {
+			heads, err := readStateHeads(r, MERGE_HEAD)
+			if err != nil || len(heads) == 0 {
+				return "", err
+			}
+			header = fmt.Sprintf("Merge commit '%s'", heads[0].String()[:7])
+		}
+		if format.ParseCommitMsg(header) != nil {
+			return header, nil
+		}
+		return format.CommitMessage(&format.CommitMessageOption{Ctype: format.ChoreCommit, Description: lowerFirst(header)}), nil
+	case git.RepositoryStateCherrypick:
+		c, err := stateCommit(r, CHERRY_PICK_HEAD)
+		if err != nil || c == nil {
+			return "", err
+		}
+		defer c.Free()
+		return c.Message(), nil
+	case git.RepositoryStateRevert:
+		c, err := stateCommit(r, REVERT_HEAD)
+		if err != nil || c == nil {
+			return "", err
+		}
+		defer c.Free()
+		return format.RevertMessage(c.Summary(), c.Id().String()), nil
+	}
+	return "", nil
+}
+
+// StateAuthor returns the author to keep for the operation in progress, nil to use the default signature.
+// Cherry-picks keep the original author.
+func StateAuthor(r *git.Repository) (*git.Signature, error) {
+	if r.State() != git.RepositoryStateCherrypick {
+		return nil, nil
+	}
+	c, err := stateCommit(r, CHERRY_PICK_HEAD)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	defer c.Free()
+	return c.Author(), nil
+}
+
+func lowerFirst(s string) string {
+	first, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(first)) + s[size:]
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// danglingCommit creates a commit on top of parent without moving any reference.
+func danglingCommit(t *testing.T, r *git.Repository, parent *git.Commit, author string, msg string) *git.Commit {
+	sig := &git.Signature{Name: author, Email: author + "@example.com", When: time.Now()}
+	tree, err := parent.Tree()
+	require.NoError(t, err)
+	oid, err := r.CreateCommit("", sig, sig, msg, tree, parent)
+	require.NoError(t, err)
+	c, err := r.LookupCommit(oid)
+	require.NoError(t, err)
+	return c
+}
+
+func writeState(t *testing.T, r *git.Repository, name string, content string) {
+	require.NoError(t, ioutil.WriteFile(filepath.Join(r.Path(), name), []byte(content), 0644))
+}
+
+func TestMergeState(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	base, err := Commit(r, "feat: base")
+	require.NoError(t, err)
+	side := danglingCommit(t, r, base, "Bob", "feat: side")
+
+	writeState(t, r, MERGE_HEAD, side.Id().String()+"\n")
+	writeState(t, r, MERGE_MSG, "Merge branch 'side'\n\n# Conflicts:\n#\tfoo\n")
+	msg, err := StateMessage(r)
+	require.NoError(t, err)
+	assert.Equal(t, "chore: merge branch 'side'", msg)
+
+	writeState(t, r, MERGE_MSG, "")
+	msg, err = StateMessage(r)
+	require.NoError(t, err)
+	assert.Equal(t, "chore: merge commit '"+side.Id().String()[:7]+"'", msg)
+
+	merge, err := Commit(r, msg)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), merge.ParentCount())
+	assert.Equal(t, base.Id(), merge.ParentId(0))
+	assert.Equal(t, side.Id(), merge.ParentId(1))
+	assert.Equal(t, git.RepositoryStateNone, r.State())
+	assert.NoFileExists(t, filepath.Join(r.Path(), MERGE_HEAD))
+}
+
+func TestCherryPickState(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	base, err := Commit(r, "feat: base")
+	require.NoError(t, err)
+	picked := danglingCommit(t, r, base, "Bob", "fix: picked")
+
+	writeState(t, r, CHERRY_PICK_HEAD, picked.Id().String()+"\n")
+	msg, err := StateMessage(r)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: picked", msg)
+
+	c, err := Commit(r, msg)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), c.ParentCount())
+	assert.Equal(t, "Bob", c.Author().Name)
+	assert.Equal(t, test.GIT_USERNAME, c.Committer().Name)
+	assert.Equal(t, git.RepositoryStateNone, r.State())
+}
+
+func TestRevertState(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+	base, err := Commit(r, "feat: base")
+	require.NoError(t, err)
+
+	writeState(t, r, REVERT_HEAD, base.Id().String()+"\n")
+	msg, err := StateMessage(r)
+	require.NoError(t, err)
+	assert.Equal(t, "revert: feat: base\n\nThis reverts commit "+base.Id().String()+".\n\nRefs: "+base.Id().String(), msg)
+
+	c, err := Commit(r, msg)
+	require.NoError(t, err)
+	assert.Equal(t, test.GIT_USERNAME, c.Author().Name)
+	assert.Equal(t, git.RepositoryStateNone, r.State())
+
+	msg, err = StateMessage(r)
+	require.NoError(t, err)
+	assert.Empty(t, msg)
+}
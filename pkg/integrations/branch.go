@@ -0,0 +1,87 @@
+package integrations
+
+import (
+	"github.com/b4nst/turbogit/pkg/format"
+	git "github.com/libgit2/git2go/v33"
+)
+
+// BranchProvider proposes commit messages from the current branch and its linked issue.
+type BranchProvider struct {
+	branch format.TugBranch
+	repo   *git.Repository
+	// Issuers looking up the linked issue, built on first use
+	issuers []Issuer
+}
+
+// NewBranchProvider returns a provider for the current branch, or nil if HEAD is not on a type/[prefix/]description branch.
+func NewBranchProvider(r *git.Repository) (*BranchProvider, error) {
+	head, err := r.Head()
+	if err != nil || !head.IsBranch() {
+		// Unborn or detached HEAD
+		return nil, nil
+	}
+	defer head.Free()
+	tb, err := format.ParseBranch(head.Shorthand())
+	if err != nil {
+		return nil, nil
+	}
+	return &BranchProvider{branch: tb, repo: r}, nil
+}
+
+// CommitMessages proposes a message from the branch, then from the linked issue if an issuer knows it.
+// Issuers failures, including their construction, are ignored, so that it works offline.
+func (bp *BranchProvider) CommitMessages(*git.Diff) ([]string, error) {
+	var msgs []string
+	add := func(cmo *format.CommitMessageOption) {
+		if cmo.Check() != nil {
+			return
+		}
+		msg := format.CommitMessage(cmo)
+		for _, m := range msgs {
+			if m == msg {
+				return
+			}
+		}
+		msgs = append(msgs, msg)
+	}
+
+	ctype := format.FindCommitType(bp.branch.WithType(bp.branch.Type, format.DefaultTypeRewrite).Type)
+	var footers []string
+	if bp.branch.Prefix != "" && format.DefaultBranchPrefix.MatchString(bp.branch.Prefix) {
+		footers = append(footers, "Refs: "+bp.branch.Prefix)
+	}
+	add(&format.CommitMessageOption{Ctype: ctype, Description: bp.branch.Description, Footers: footers})
+
+	if issue := bp.linkedIssue(); issue != nil {
+		itype := format.FindCommitType(issue.ToBranch(format.DefaultTypeRewrite).Type)
+		if itype == format.NilCommit {
+			itype = ctype
+		}
+		add(&format.CommitMessageOption{Ctype: itype, Description: issue.Name, Footers: []string{"Refs: " + issue.ID}})
+	}
+
+	return msgs, nil
+}
+
+// linkedIssue returns the issue whose ID is the branch prefix, if any.
+func (bp *BranchProvider) linkedIssue() *IssueDescription {
+	if bp.branch.Prefix == "" {
+		return nil
+	}
+	if bp.issuers == nil && bp.repo != nil {
+		// Misconfigured or unreachable issuers are ignored as well
+		bp.issuers, _ = Issuers(bp.repo)
+	}
+	for _, issuer := range bp.issuers {
+		issues, err := issuer.Search()
+		if err != nil {
+			continue
+		}
+		for _, issue := range issues {
+			if issue.ID == bp.branch.Prefix {
+				return &issue
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package integrations
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIssuer struct {
+	issues []IssueDescription
+	err    error
+}
+
+func (fi fakeIssuer) Search() ([]IssueDescription, error) {
+	return fi.issues, fi.err
+}
+
+func TestNewBranchProvider(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	// Unborn HEAD
+	bp, err := NewBranchProvider(r)
+	assert.NoError(t, err)
+	assert.Nil(t, bp)
+
+	c, err := tugit.Commit(r, "feat: initial commit")
+	require.NoError(t, err)
+	b, err := r.CreateBranch("fix/PROJ-12/login-timeout", c, false)
+	require.NoError(t, err)
+	require.NoError(t, r.SetHead(b.Reference.Name()))
+
+	bp, err = NewBranchProvider(r)
+	assert.NoError(t, err)
+	require.NotNil(t, bp)
+	assert.Equal(t, format.TugBranch{Type: "fix", Prefix: "PROJ-12", Description: "Login timeout"}, bp.branch)
+
+	// No remote, the GitLab issuer cannot be built
+	msgs, err := bp.CommitMessages(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fix: Login timeout\n\nRefs: PROJ-12"}, msgs)
+}
+
+func TestBranchProviderCommitMessages(t *testing.T) {
+	tcs := map[string]struct {
+		branch   format.TugBranch
+		issuers  []Issuer
+		expected []string
+	}{
+		"Branch only": {
+			branch:   format.TugBranch{Type: "fix", Prefix: "PROJ-12", Description: "Login timeout"},
+			expected: []string{"fix: Login timeout\n\nRefs: PROJ-12"},
+		},
+		"Rewritten type": {
+			branch:   format.TugBranch{Type: "feature", Description: "Dark mode"},
+			expected: []string{"feat: Dark mode"},
+		},
+		"No type": {
+			branch:   format.TugBranch{Type: "user", Prefix: "alice", Description: "Stuff"},
+			expected: nil,
+		},
+		"Linked issue": {
+			branch: format.TugBranch{Type: "fix", Prefix: "PROJ-12", Description: "Login timeout"},
+			issuers: []Issuer{
+				fakeIssuer{err: errors.New("offline")},
+				fakeIssuer{issues: []IssueDescription{
+					{ID: "PROJ-1", Name: "Other", Type: "Story"},
+					{ID: "PROJ-12", Name: "Users are logged out after 5 minutes", Type: "Bug"},
+				}},
+			},
+			expected: []string{
+				"fix: Login timeout\n\nRefs: PROJ-12",
+				"fix: Users are logged out after 5 minutes\n\nRefs: PROJ-12",
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			bp := &BranchProvider{branch: tc.branch, issuers: tc.issuers}
+			msgs, err := bp.CommitMessages(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, msgs)
+		})
+	}
+}
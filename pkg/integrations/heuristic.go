@@ -0,0 +1,263 @@
+package integrations
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/scope"
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Maximum number of symbols listed in a description
+	MAX_DESCRIPTION_SYMBOLS = 3
+)
+
+var (
+	testFileRe  = regexp.MustCompile(`(?:_test\.go|\.(?:spec|test)\.[^/]+|_spec\.rb)$|(?:^|/)(?:tests?|__tests__|testdata)/`)
+	docFileRe   = regexp.MustCompile(`(?i)\.(?:md|rst|adoc|txt)$|(?:^|/)docs?/|^(?:LICENSE|AUTHORS|CHANGELOG)`)
+	ciFileRe    = regexp.MustCompile(`^(?:\.github/workflows/|\.gitlab-ci\.yml$|\.gitlab/ci/|\.circleci/|\.travis\.yml$|\.drone\.yml$|Jenkinsfile$|azure-pipelines\.yml$|\.buildkite/)`)
+	buildFileRe = regexp.MustCompile(`(?:^|/)(?:go\.mod|go\.sum|Makefile|[^/]*\.mk|Dockerfile|package(?:-lock)?\.json|yarn\.lock|pnpm-lock\.yaml|Cargo\.(?:toml|lock)|requirements[^/]*\.txt|pyproject\.toml|\.goreleaser\.ya?ml)$`)
+
+	// Declarations of common languages, the first non empty group is the symbol name
+	symbolRe = regexp.MustCompile(`^\s*(?:func\s+(?:\([^)]*\)\s*)?(\w+)|type\s+(\w+)|(?:async\s+)?def\s+(\w+)|(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)|(?:export\s+)?(?:async\s+)?function\s+(\w+)|(?:pub\s+)?fn\s+(\w+))`)
+)
+
+// HeuristicProvider proposes commit messages from the staged diff alone, without network.
+type HeuristicProvider struct {
+	scopes scope.Map
+}
+
+// fileChange is a file touched by a diff.
+type fileChange struct {
+	Path   string
+	Status git.Delta
+}
+
+// NewHeuristicProvider returns a heuristic provider using the repository scope map.
+func NewHeuristicProvider(r *git.Repository) (*HeuristicProvider, error) {
+	m, err := scope.Load(r)
+	if err != nil {
+		return nil, err
+	}
+	return &HeuristicProvider{scopes: m}, nil
+}
+
+func (hp *HeuristicProvider) CommitMessages(diff *git.Diff) ([]string, error) {
+	n, err := diff.NumDeltas()
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]fileChange, 0, n)
+	for i := 0; i < n; i++ {
+		delta, err := diff.Delta(i)
+		if err != nil {
+			return nil, err
+		}
+		p := delta.NewFile.Path
+		if p == "" {
+			p = delta.OldFile.Path
+		}
+		changes = append(changes, fileChange{Path: p, Status: delta.Status})
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	patch, err := tugit.PatchFromDiff(diff)
+	if err != nil {
+		return nil, err
+	}
+	return hp.messages(changes, patch), nil
+}
+
+// messages proposes messages, the most likely first.
+func (hp *HeuristicProvider) messages(changes []fileChange, patch string) []string {
+	sc := hp.scope(changes)
+	desc := describe(changes, patch)
+	var msgs []string
+	for _, ct := range inferTypes(changes) {
+		msgs = append(msgs, format.CommitMessage(&format.CommitMessageOption{Ctype: ct, Scope: sc, Description: desc}))
+	}
+	return msgs
+}
+
+// inferTypes returns the likely commit types of the changes, the most likely first.
+func inferTypes(changes []fileChange) []format.CommitType {
+	all := func(re *regexp.Regexp) bool {
+		for _, c := range changes {
+			if !re.MatchString(c.Path) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case all(testFileRe):
+		return []format.CommitType{format.TestCommit}
+	case all(ciFileRe):
+		return []format.CommitType{format.CiCommit}
+	case all(buildFileRe):
+		return []format.CommitType{format.BuildCommit}
+	case all(docFileRe):
+		return []format.CommitType{format.DocCommit}
+	}
+
+	added, deleted := false, false
+	for _, c := range changes {
+		if testFileRe.MatchString(c.Path) || docFileRe.MatchString(c.Path) {
+			continue
+		}
+		switch c.Status {
+		case git.DeltaAdded:
+			added = true
+		case git.DeltaDeleted:
+			deleted = true
+		}
+	}
+	switch {
+	case added:
+		return []format.CommitType{format.FeatureCommit, format.FixCommit}
+	case deleted:
+		return []format.CommitType{format.RefactorCommit, format.ChoreCommit}
+	default:
+		return []format.CommitType{format.FixCommit, format.RefactorCommit, format.FeatureCommit}
+	}
+}
+
+// scope returns the scope of the changes from the scope map, or the deepest common directory.
+func (hp *HeuristicProvider) scope(changes []fileChange) string {
+	paths := make([]string, len(changes))
+	for i, c := range changes {
+		paths[i] = c.Path
+	}
+	if scopes := hp.scopes.Scopes(paths); len(scopes) == 1 {
+		return scopes[0]
+	}
+
+	common := path.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for common != "." && !strings.HasPrefix(p, common+"/") {
+			common = path.Dir(common)
+		}
+	}
+	if common == "." {
+		return ""
+	}
+	return path.Base(common)
+}
+
+// describe drafts a description from the added, removed and updated symbols, or from the file names.
+func describe(changes []fileChange, patch string) string {
+	added, removed, updated := symbols(patch)
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "add "+enumerate(added))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "remove "+enumerate(removed))
+	}
+	if len(parts) == 0 && len(updated) > 0 {
+		parts = append(parts, "update "+enumerate(updated))
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, " and ")
+	}
+
+	names := make([]string, len(changes))
+	verb := ""
+	for i, c := range changes {
+		names[i] = path.Base(c.Path)
+		v := "update"
+		switch c.Status {
+		case git.DeltaAdded:
+			v = "add"
+		case git.DeltaDeleted:
+			v = "remove"
+		}
+		if verb == "" {
+			verb = v
+		} else if verb != v {
+			verb = "update"
+		}
+	}
+	return verb + " " + enumerate(names)
+}
+
+// symbols returns the symbols declared on added lines only, on removed lines only,
+// and in the context of modified hunks.
+func symbols(patch string) (added []string, removed []string, updated []string) {
+	plus, minus := map[string]bool{}, map[string]bool{}
+	var plusOrder, minusOrder, hunkOrder []string
+	hunks := map[string]bool{}
+	for _, l := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(l, "+++") || strings.HasPrefix(l, "---"):
+		case strings.HasPrefix(l, "@@"):
+			// Hunk header context, e.g. '@@ -1,2 +1,3 @@ func Foo() {'
+			if i := strings.Index(l[2:], "@@"); i >= 0 {
+				if s := symbol(l[i+4:]); s != "" && !hunks[s] {
+					hunks[s] = true
+					hunkOrder = append(hunkOrder, s)
+				}
+			}
+		case strings.HasPrefix(l, "+"):
+			if s := symbol(l[1:]); s != "" && !plus[s] {
+				plus[s] = true
+				plusOrder = append(plusOrder, s)
+			}
+		case strings.HasPrefix(l, "-"):
+			if s := symbol(l[1:]); s != "" && !minus[s] {
+				minus[s] = true
+				minusOrder = append(minusOrder, s)
+			}
+		}
+	}
+	for _, s := range plusOrder {
+		if !minus[s] {
+			added = append(added, s)
+		} else {
+			// Signature change
+			updated = append(updated, s)
+		}
+	}
+	for _, s := range minusOrder {
+		if !plus[s] {
+			removed = append(removed, s)
+		}
+	}
+	for _, s := range hunkOrder {
+		if !plus[s] && !minus[s] {
+			updated = append(updated, s)
+		}
+	}
+	return
+}
+
+// symbol returns the name declared on the line, if any.
+func symbol(line string) string {
+	m := symbolRe.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	for _, g := range m[1:] {
+		if g != "" {
+			return g
+		}
+	}
+	return ""
+}
+
+// enumerate lists at most MAX_DESCRIPTION_SYMBOLS items.
+func enumerate(items []string) string {
+	if len(items) <= MAX_DESCRIPTION_SYMBOLS {
+		if len(items) == 1 {
+			return items[0]
+		}
+		return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:MAX_DESCRIPTION_SYMBOLS], ", "), len(items)-MAX_DESCRIPTION_SYMBOLS)
+}
@@ -0,0 +1,79 @@
+package integrations
+
+import (
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/format"
+	tugit "github.com/b4nst/turbogit/pkg/git"
+	"github.com/b4nst/turbogit/pkg/scope"
+	"github.com/b4nst/turbogit/pkg/test"
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferTypes(t *testing.T) {
+	tcs := map[string]struct {
+		changes  []fileChange
+		expected format.CommitType
+	}{
+		"Tests":   {[]fileChange{{"pkg/a_test.go", git.DeltaModified}, {"pkg/testdata/x.json", git.DeltaAdded}}, format.TestCommit},
+		"Docs":    {[]fileChange{{"README.md", git.DeltaModified}, {"assets/docs/usage.md", git.DeltaModified}}, format.DocCommit},
+		"CI":      {[]fileChange{{".github/workflows/ci.yml", git.DeltaModified}}, format.CiCommit},
+		"Build":   {[]fileChange{{"go.mod", git.DeltaModified}, {"go.sum", git.DeltaModified}}, format.BuildCommit},
+		"Feature": {[]fileChange{{"pkg/new.go", git.DeltaAdded}, {"pkg/new_test.go", git.DeltaAdded}}, format.FeatureCommit},
+		"Removal": {[]fileChange{{"pkg/old.go", git.DeltaDeleted}}, format.RefactorCommit},
+		"Fix":     {[]fileChange{{"pkg/a.go", git.DeltaModified}, {"README.md", git.DeltaModified}}, format.FixCommit},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, inferTypes(tc.changes)[0])
+		})
+	}
+}
+
+func TestHeuristicScope(t *testing.T) {
+	rule, err := scope.NewRule("pkg/format", "fmt")
+	require.NoError(t, err)
+	hp := &HeuristicProvider{scopes: scope.Map{rule}}
+
+	assert.Equal(t, "fmt", hp.scope([]fileChange{{Path: "pkg/format/a.go"}, {Path: "pkg/format/b.go"}}))
+	assert.Equal(t, "pkg", hp.scope([]fileChange{{Path: "pkg/format/a.go"}, {Path: "pkg/git/b.go"}}))
+	assert.Equal(t, "git", hp.scope([]fileChange{{Path: "pkg/git/a.go"}, {Path: "pkg/git/sub/b.go"}}))
+	assert.Equal(t, "", hp.scope([]fileChange{{Path: "main.go"}, {Path: "pkg/git/b.go"}}))
+}
+
+func TestDescribe(t *testing.T) {
+	patch := `diff --git a/pkg/a.go b/pkg/a.go
+--- a/pkg/a.go
++++ b/pkg/a.go
+@@ -1,5 +1,9 @@ func Existing() {
++func NewThing() error {
++type Widget struct {
+-func OldThing() {
++func (w *Widget) Changed(a int) {
+-func (w *Widget) Changed() {
+`
+	assert.Equal(t, "add NewThing and Widget and remove OldThing", describe(nil, patch))
+	assert.Equal(t, "update Existing", describe(nil, "@@ -1,2 +1,2 @@ func Existing() {\n+\treturn nil\n"))
+	assert.Equal(t, "add a.go and b.go", describe([]fileChange{{"pkg/a.go", git.DeltaAdded}, {"b.go", git.DeltaAdded}}, ""))
+	assert.Equal(t, "update a.go, b.go, c.go and 1 more", describe([]fileChange{{"a.go", git.DeltaAdded}, {"b.go", git.DeltaModified}, {"c.go", git.DeltaModified}, {"d.go", git.DeltaModified}}, ""))
+}
+
+func TestHeuristicCommitMessages(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	test.InitRepoConf(t, r)
+
+	test.StageNewFile(t, r)
+	diff, err := tugit.StagedDiff(r)
+	require.NoError(t, err)
+
+	hp, err := NewHeuristicProvider(r)
+	require.NoError(t, err)
+	msgs, err := hp.CommitMessages(diff)
+	assert.NoError(t, err)
+	require.NotEmpty(t, msgs)
+	assert.Regexp(t, `^feat: add `, msgs[0])
+}
@@ -38,6 +38,22 @@ func Issuers(r *git.Repository) (issuers []Issuer, err error) {
 }
 
 func Commiters(r *git.Repository) (commiters []Commiter, err error) {
+	// Branch
+	bp, err := NewBranchProvider(r)
+	if err != nil {
+		return nil, err
+	}
+	if bp != nil {
+		commiters = append(commiters, bp)
+	}
+
+	// Heuristic
+	hp, err := NewHeuristicProvider(r)
+	if err != nil {
+		return nil, err
+	}
+	commiters = append(commiters, hp)
+
 	// OpenAI
 	oai, err := NewOpenAIProvider(r)
 	if err != nil {
@@ -0,0 +1,123 @@
+// Package scope maps repository paths to conventional commit scopes.
+package scope
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	git "github.com/libgit2/git2go/v33"
+)
+
+const (
+	// Git config section holding the scope map, as tug.scope.<scope> = <glob> entries
+	CONFIG_PREFIX = "tug.scope."
+)
+
+// Rule maps the paths matching a glob to a scope.
+type Rule struct {
+	Glob  string
+	Scope string
+	re    *regexp.Regexp
+}
+
+// NewRule compiles a glob rule. '*' and '?' do not match '/', '**' matches any number of directories,
+// and a glob without wildcard matches the path itself and anything below it.
+func NewRule(glob string, scope string) (*Rule, error) {
+	glob = strings.Trim(glob, "/")
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// '**/' matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("(?:/.*)?$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob '%s' for scope %s: %w", glob, scope, err)
+	}
+	return &Rule{Glob: glob, Scope: scope, re: re}, nil
+}
+
+// Match returns true if the path matches the rule glob.
+func (r *Rule) Match(path string) bool {
+	return r.re.MatchString(path)
+}
+
+// Map maps paths to scopes. The most specific (longest) matching glob wins.
+type Map []*Rule
+
+// Load reads the scope map from the repository configuration.
+func Load(r *git.Repository) (Map, error) {
+	c, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Free()
+	it, err := c.NewIteratorGlob(`^` + regexp.QuoteMeta(CONFIG_PREFIX))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Free()
+
+	var m Map
+	for {
+		entry, err := it.Next()
+		if git.IsErrorCode(err, git.ErrorCodeIterOver) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rule, err := NewRule(entry.Value, strings.TrimPrefix(entry.Name, CONFIG_PREFIX))
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, rule)
+	}
+	sort.SliceStable(m, func(i, j int) bool {
+		return len(m[i].Glob) > len(m[j].Glob)
+	})
+	return m, nil
+}
+
+// Of returns the scope of a path, or an empty string if no rule matches.
+func (m Map) Of(path string) string {
+	for _, rule := range m {
+		if rule.Match(path) {
+			return rule.Scope
+		}
+	}
+	return ""
+}
+
+// Scopes returns the distinct scopes of the paths, in order of appearance. Unmapped paths are ignored.
+func (m Map) Scopes(paths []string) []string {
+	var scopes []string
+	seen := map[string]bool{}
+	for _, p := range paths {
+		if s := m.Of(p); s != "" && !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,57 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/b4nst/turbogit/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleMatch(t *testing.T) {
+	tcs := map[string]struct {
+		glob     string
+		path     string
+		expected bool
+	}{
+		"Directory":         {"pkg/api", "pkg/api/server.go", true},
+		"Directory slash":   {"pkg/api/", "pkg/api/v1/server.go", true},
+		"Directory itself":  {"pkg/api", "pkg/api", true},
+		"Other directory":   {"pkg/api", "pkg/apiv2/server.go", false},
+		"Star":              {"cmd/*.go", "cmd/log.go", true},
+		"Star no slash":     {"cmd/*.go", "cmd/sub/log.go", false},
+		"Double star":       {"**/*.md", "assets/docs/usage.md", true},
+		"Double star root":  {"**/*.md", "README.md", true},
+		"Double star infix": {"pkg/**/test", "pkg/a/b/test/git.go", true},
+		"Question":          {"v?/api", "v1/api/x.go", true},
+		"Meta chars":        {"a+b.c", "a+b.c", true},
+		"Meta chars escape": {"a+b.c", "aab-c", false},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			rule, err := NewRule(tc.glob, "scope")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, rule.Match(tc.path))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	r := test.TestRepo(t)
+	defer test.CleanupRepo(t, r)
+	c, err := r.Config()
+	require.NoError(t, err)
+	require.NoError(t, c.SetString("tug.scope.pkg", "pkg"))
+	require.NoError(t, c.SetString("tug.scope.format", "pkg/format"))
+	require.NoError(t, c.SetString("tug.scope.docs", "**/*.md"))
+
+	m, err := Load(r)
+	require.NoError(t, err)
+	assert.Len(t, m, 3)
+	assert.Equal(t, "format", m.Of("pkg/format/commit.go"))
+	assert.Equal(t, "pkg", m.Of("pkg/git/commit.go"))
+	assert.Equal(t, "docs", m.Of("README.md"))
+	assert.Equal(t, "", m.Of("cmd/log.go"))
+	assert.Equal(t, []string{"format", "pkg"}, m.Scopes([]string{"pkg/format/a.go", "cmd/log.go", "pkg/git/b.go", "pkg/format/c.go"}))
+}